@@ -1,8 +1,14 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"flag"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gorilla/websocket"
 	"github.com/manifoldco/promptui"
@@ -12,6 +18,10 @@ import (
 var player_username string
 var opponent_username string
 
+var server_host = "ee60a3ab.ngrok.io"
+
+var drawStripes = flag.Bool("stripes", false, "draw flags as colored stripes, for terminals without emoji")
+
 type Game struct {
 	Conn *websocket.Conn
 }
@@ -23,18 +33,70 @@ type message struct {
 
 type question struct {
 	Id string
+	Kind string
 	Country string
+	Capital string
+	Prompt string
+	Metric string
+	Region string
+	Outline []string
+	Flag string
+	Stripes *stripes
 	Options []string
+	OptionIds []string
 }
 
 type answer struct {
 	Id string
+	Kind string
+	OptionId string
+	Selections []string
+	Order []string
 	Capital string
+	Lat float64
+	Lon float64
+}
+
+type hint struct {
+	Kind string
+	Text string
+	Eliminated []string
+	Penalty float64
+	Error string
+}
+
+// hinter asks the server for hints about the question being answered.
+type hinter struct {
+	conn *websocket.Conn
+	questionId string
+	kinds []string
+	eliminated map[string]bool
+}
+
+var hintLabels = map[string]string{
+	"firstLetter": "First letter of the capital",
+	"continent":   "Continent",
+	"eliminate":   "Take out wrong options",
+}
+
+type stripes struct {
+	Direction string
+	Colors []string
 }
 
 type status struct {
 	Result bool `json:"result"`
 	Message string `json:"message"`
+	Reveal string `json:"reveal"`
+	Explanation *explanation `json:"explanation"`
+}
+
+type explanation struct {
+	Country string
+	Flag string
+	Population int
+	Region string
+	Fact string
 }
 
 type gameOver struct{
@@ -46,40 +108,536 @@ type startGame struct {
 	GameID string `json:"gameID"`
 }
 
+type practiceRequest struct {
+	Player string `json:"player"`
+}
+
+type practiceResponse struct {
+	GameID int `json:"gameID"`
+	Due int `json:"due"`
+}
 
-func playQuestion(q interface{}) answer {
+type dailyResponse struct {
+	GameID int `json:"gameID"`
+	Date string `json:"date"`
+}
+
+type dailyScore struct {
+	Player string `json:"player"`
+	Score int `json:"score"`
+}
+
+
+func playQuestion(q interface{}, conn *websocket.Conn) answer {
 	question := question{}
 	mapstructure.Decode(q, &question)
-	question_prompt := fmt.Sprintf("What is the capital of %s?", question.Country)
-	ans_p := promptui.Select{
-		Label:        question_prompt,
-		Items:        question.Options,
-		HideSelected: false,
-		Templates: &promptui.SelectTemplates{
-			Selected: fmt.Sprintf(`{{ "%s" }} {{ . | faint }}`, question_prompt),
-		},
+	hints := newHinter(conn, question)
+	switch question.Kind {
+	case "reverseCapital":
+		question_prompt := fmt.Sprintf("Which country has %s as its capital?", question.Capital)
+		return answer{
+			Id:       question.Id,
+			Kind:     question.Kind,
+			OptionId: selectOption(question_prompt, question, hints),
+		}
+	case "borders":
+		question_prompt := fmt.Sprintf("Which of these countries borders %s?", question.Country)
+		return answer{
+			Id:       question.Id,
+			Kind:     question.Kind,
+			OptionId: selectOption(question_prompt, question, hints),
+		}
+	case "borderCount":
+		question_prompt := fmt.Sprintf("How many countries border %s?", question.Country)
+		return answer{
+			Id:       question.Id,
+			Kind:     question.Kind,
+			OptionId: selectOption(question_prompt, question, hints),
+		}
+	case "compare":
+		question_prompt := fmt.Sprintf("Which has the larger %s?", question.Metric)
+		return answer{
+			Id:       question.Id,
+			Kind:     question.Kind,
+			OptionId: selectOption(question_prompt, question, hints),
+		}
+	case "outline":
+		for _, line := range question.Outline {
+			fmt.Println(line)
+		}
+		return answer{
+			Id:       question.Id,
+			Kind:     question.Kind,
+			OptionId: selectOption("Which country has this shape?", question, hints),
+		}
+	case "flag":
+		if *drawStripes && question.Stripes != nil {
+			fmt.Print(question.Stripes.draw(24, 8))
+		} else {
+			fmt.Printf("%s\n", question.Flag)
+		}
+		return answer{
+			Id:       question.Id,
+			Kind:     question.Kind,
+			OptionId: selectOption("Which country does this flag belong to?", question, hints),
+		}
+	case "trueFalse":
+		question_prompt := fmt.Sprintf("True or false: %s is the capital of %s", question.Capital, question.Country)
+		return answer{
+			Id:       question.Id,
+			Kind:     question.Kind,
+			OptionId: selectOption(question_prompt, question, hints),
+		}
+	case "selectAll":
+		question_prompt := fmt.Sprintf("Which of these are in %s? Select all that apply", question.Region)
+		return answer{
+			Id:         question.Id,
+			Kind:       question.Kind,
+			Selections: selectOptions(question_prompt, question, hints),
+		}
+	case "ordering":
+		question_prompt := fmt.Sprintf("Put them in order, from the largest %s to the smallest", question.Metric)
+		switch question.Metric {
+		case "longitude":
+			question_prompt = "Put them in order, from west to east"
+		case "latitude":
+			question_prompt = "Put them in order, from north to south"
+		}
+		return answer{
+			Id:    question.Id,
+			Kind:  question.Kind,
+			Order: orderOptions(question_prompt, question, hints),
+		}
+	case "pack":
+		return answer{
+			Id:       question.Id,
+			Kind:     question.Kind,
+			OptionId: selectOption(question.Prompt, question, hints),
+		}
+	case "freeText":
+		question_prompt := fmt.Sprintf("What is the capital of %s?", question.Country)
+		return answer{
+			Id:      question.Id,
+			Kind:    question.Kind,
+			Capital: typeAnswer(question_prompt, hints),
+		}
+	case "coordinates":
+		place := question.Country
+		if question.Capital != "" {
+			place = question.Capital
+		}
+		fmt.Printf("Where is %s? North and east are positive.\n", place)
+		return answer{
+			Id:   question.Id,
+			Kind: question.Kind,
+			Lat:  typeCoordinate("Latitude", 90, hints),
+			Lon:  typeCoordinate("Longitude", 180, hints),
+		}
+	default:
+		question_prompt := fmt.Sprintf("What is the capital of %s?", question.Country)
+		return answer{
+			Id:       question.Id,
+			Kind:     question.Kind,
+			OptionId: selectOption(question_prompt, question, hints),
+		}
 	}
-	_, ans, err := ans_p.Run()
+}
+
+// draw paints the stripes with truecolor escape codes. Each character holds
+// two pixels, the top one in the foreground of a half block.
+func (s stripes) draw(width int, height int) string {
+	var out strings.Builder
+	colorAt := func(x int, y int) string {
+		if s.Direction == "vertical" {
+			return s.Colors[x*len(s.Colors)/width]
+		}
+		return s.Colors[y*len(s.Colors)/(height*2)]
+	}
+	for row := 0; row < height; row++ {
+		for x := 0; x < width; x++ {
+			top, bottom := rgb(colorAt(x, row*2)), rgb(colorAt(x, row*2+1))
+			fmt.Fprintf(&out, "\x1b[38;2;%sm\x1b[48;2;%sm▀", top, bottom)
+		}
+		out.WriteString("\x1b[0m\n")
+	}
+	return out.String()
+}
+
+// printStatus shows how the player did on a question, also when they ran
+// out of time.
+func printStatus(content interface{}) {
+	status := status{}
+	mapstructure.Decode(content, &status)
+	fmt.Println(status.Message)
+	if status.Reveal != "" {
+		fmt.Println(status.Reveal)
+	}
+	if status.Explanation != nil {
+		status.Explanation.print()
+	}
+}
+
+// print shows the explanation while the next question is on its way.
+func (e explanation) print() {
+	line := fmt.Sprintf("%s, %s, %s people", e.Country, e.Region, groupDigits(e.Population))
+	if !*drawStripes {
+		line = e.Flag + " " + line
+	}
+	fmt.Println(line)
+	if e.Fact != "" {
+		fmt.Printf("   %s\n", e.Fact)
+	}
+}
+
+// groupDigits writes n with commas between thousands.
+func groupDigits(n int) string {
+	digits := strconv.Itoa(n)
+	var out strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			out.WriteByte(',')
+		}
+		out.WriteRune(digit)
+	}
+	return out.String()
+}
+
+// rgb turns a color like #CE1126 into the r;g;b of an escape code.
+func rgb(hex string) string {
+	value, _ := strconv.ParseUint(strings.TrimPrefix(hex, "#"), 16, 32)
+	return fmt.Sprintf("%d;%d;%d", value>>16, value>>8&0xFF, value&0xFF)
+}
+
+// selectOption returns the ID of the option picked. The last item asks for a
+// hint instead, when any applies.
+func selectOption(question_prompt string, question question, hints *hinter) string {
+	for {
+		var items, ids []string
+		for i, option := range question.Options {
+			if !hints.eliminated[question.OptionIds[i]] {
+				items = append(items, option)
+				ids = append(ids, question.OptionIds[i])
+			}
+		}
+		if hints.offered() {
+			items = append(items, "💡 Hint")
+		}
+		ans_p := promptui.Select{
+			Label:        question_prompt,
+			Items:        items,
+			HideSelected: false,
+			Templates: &promptui.SelectTemplates{
+				Selected: fmt.Sprintf(`{{ "%s" }} {{ . | faint }}`, question_prompt),
+			},
+		}
+		i, _, err := ans_p.Run()
+		if err != nil {
+			panic(err)
+		}
+		if i < len(ids) {
+			return ids[i]
+		}
+		hints.ask()
+	}
+}
+
+// newHinter offers the hints the server gives for questions of this kind.
+func newHinter(conn *websocket.Conn, question question) *hinter {
+	var kinds []string
+	switch question.Kind {
+	case "capital", "freeText":
+		kinds = append(kinds, "firstLetter")
+	case "reverseCapital", "coordinates", "outline", "flag":
+		kinds = append(kinds, "continent")
+	}
+	// only questions with a single right option, and two to spare, lose some
+	if len(question.Options) >= 3 && question.Kind != "selectAll" && question.Kind != "ordering" {
+		kinds = append(kinds, "eliminate")
+	}
+	return &hinter{conn, question.Id, kinds, make(map[string]bool)}
+}
+
+// offered tells whether any hint applies to the question.
+func (h *hinter) offered() bool {
+	return len(h.kinds) > 0
+}
+
+// ask lets the player pick a hint, then waits for the server's and shows it.
+func (h *hinter) ask() {
+	items := make([]string, len(h.kinds))
+	for i, kind := range h.kinds {
+		items[i] = hintLabels[kind]
+	}
+	hint_p := promptui.Select{
+		Label: "Which hint? They cost points",
+		Items: items,
+	}
+	i, _, err := hint_p.Run()
 	if err != nil {
 		panic(err)
 	}
-	return answer{
-		Id:      question.Id,
-		Capital: ans,
+	h.conn.WriteJSON(message{"hint", map[string]string{"id": h.questionId, "kind": h.kinds[i]}})
+	m := message{}
+	if err := h.conn.ReadJSON(&m); err != nil {
+		fmt.Println(err)
+		return
+	}
+	if m.Type != "hint" {
+		printStatus(m.Content)
+		return
+	}
+	for _, id := range printHint(m.Content).Eliminated {
+		h.eliminated[id] = true
+	}
+}
+
+// printHint shows the hint the server sent and returns it.
+func printHint(content interface{}) hint {
+	received := hint{}
+	mapstructure.Decode(content, &received)
+	if received.Error != "" {
+		fmt.Println(received.Error)
+		return hint{}
+	}
+	fmt.Printf("💡 %s (-%.0f%% of the points)\n", received.Text, received.Penalty*100)
+	return received
+}
+
+// selectOptions lets the player tick any number of options and returns the
+// IDs of those ticked. The last item asks for a hint, when any applies.
+func selectOptions(question_prompt string, question question, hints *hinter) []string {
+	ticked := make([]bool, len(question.Options))
+	cursor := 0
+	for {
+		items := make([]string, 0, len(question.Options)+1)
+		for i, option := range question.Options {
+			box := "[ ]"
+			if ticked[i] {
+				box = "[x]"
+			}
+			items = append(items, fmt.Sprintf("%s %s", box, option))
+		}
+		items = append(items, "Done")
+		if hints.offered() {
+			items = append(items, "💡 Hint")
+		}
+		ans_p := promptui.Select{
+			Label:        question_prompt,
+			Items:        items,
+			CursorPos:    cursor,
+			HideSelected: true,
+		}
+		i, _, err := ans_p.Run()
+		if err != nil {
+			panic(err)
+		}
+		if i == len(question.Options) {
+			break
+		}
+		if i == len(question.Options)+1 {
+			hints.ask()
+			cursor = i
+			continue
+		}
+		ticked[i] = !ticked[i]
+		cursor = i
 	}
+	selections := []string{}
+	for i, t := range ticked {
+		if t {
+			selections = append(selections, question.OptionIds[i])
+		}
+	}
+	return selections
 }
 
-func (game *Game) initGame(socket_url string, player string, opponent string) {
-	game.connectToSocket(socket_url, player, opponent)
+// orderOptions has the player pick the options one after the other, with the
+// order so far in the label, and returns their IDs in that order. Picking
+// the last one placed takes it back, and the last item asks for a hint when
+// any applies.
+func orderOptions(question_prompt string, question question, hints *hinter) []string {
+	var order []int
+	placed := make(map[int]bool)
+	for len(order) < len(question.Options) {
+		var sofar []string
+		for _, i := range order {
+			sofar = append(sofar, question.Options[i])
+		}
+		var items []string
+		var indexes []int
+		for i, option := range question.Options {
+			if !placed[i] {
+				items = append(items, option)
+				indexes = append(indexes, i)
+			}
+		}
+		if len(order) > 0 {
+			items = append(items, "↩ Take back "+question.Options[order[len(order)-1]])
+		}
+		if hints.offered() {
+			items = append(items, "💡 Hint")
+		}
+		ans_p := promptui.Select{
+			Label:        fmt.Sprintf("%s [%s]", question_prompt, strings.Join(sofar, " → ")),
+			Items:        items,
+			HideSelected: true,
+		}
+		i, _, err := ans_p.Run()
+		if err != nil {
+			panic(err)
+		}
+		if hints.offered() && i == len(items)-1 {
+			hints.ask()
+			continue
+		}
+		if i == len(indexes) {
+			delete(placed, order[len(order)-1])
+			order = order[:len(order)-1]
+			continue
+		}
+		placed[indexes[i]] = true
+		order = append(order, indexes[i])
+	}
+	ids := make([]string, len(order))
+	labels := make([]string, len(order))
+	for i, option := range order {
+		ids[i] = question.OptionIds[option]
+		labels[i] = question.Options[option]
+	}
+	fmt.Printf("%s: %s\n", question_prompt, strings.Join(labels, " → "))
+	return ids
+}
+
+// typeCoordinate returns the number typed, between -limit and limit. Typing
+// ? asks for a hint instead.
+func typeCoordinate(label string, limit float64, hints *hinter) float64 {
+	ans_p := promptui.Prompt{
+		Label: label + " (? for a hint)",
+		Validate: func(input string) error {
+			if input == "?" {
+				return nil
+			}
+			value, err := strconv.ParseFloat(input, 64)
+			if err != nil || value < -limit || value > limit {
+				return fmt.Errorf("Enter a number between -%.0f and %.0f", limit, limit)
+			}
+			return nil
+		},
+	}
+	for {
+		ans, err := ans_p.Run()
+		if err != nil {
+			panic(err)
+		}
+		if ans != "?" {
+			value, _ := strconv.ParseFloat(ans, 64)
+			return value
+		}
+		hints.ask()
+	}
+}
+
+// typeAnswer returns the text typed. Typing ? asks for a hint instead.
+func typeAnswer(question_prompt string, hints *hinter) string {
+	ans_p := promptui.Prompt{
+		Label: question_prompt + " (? for a hint)",
+	}
+	for {
+		ans, err := ans_p.Run()
+		if err != nil {
+			panic(err)
+		}
+		if ans != "?" {
+			return ans
+		}
+		hints.ask()
+	}
 }
 
-func (game *Game) connectToSocket(url string, player string, opponent string) {
+// startPractice asks the server for a solo game scheduled from the player's
+// past reviews and returns its ID.
+func startPractice(player string) (string, error) {
+	requestBody, err := json.Marshal(practiceRequest{Player: player})
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.Post(fmt.Sprintf("http://%s/practice", server_host), "application/json", bytes.NewBuffer(requestBody))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("practice failed: %s", body)
+	}
+	practice := practiceResponse{}
+	if err := json.Unmarshal(body, &practice); err != nil {
+		return "", err
+	}
+	fmt.Printf("%d countries due for review today 📚\n", practice.Due)
+	return strconv.Itoa(practice.GameID), nil
+}
+
+// startDaily enters the player in today's challenge and returns the game's ID
+// along with the challenge's date.
+func startDaily(player string) (string, string, error) {
+	requestBody, err := json.Marshal(practiceRequest{Player: player})
+	if err != nil {
+		return "", "", err
+	}
+	resp, err := http.Post(fmt.Sprintf("http://%s/daily", server_host), "application/json", bytes.NewBuffer(requestBody))
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", "", err
+	}
+	if resp.StatusCode != http.StatusCreated {
+		return "", "", fmt.Errorf("daily challenge failed: %s", body)
+	}
+	daily := dailyResponse{}
+	if err := json.Unmarshal(body, &daily); err != nil {
+		return "", "", err
+	}
+	fmt.Printf("Daily challenge of %s 📅\n", daily.Date)
+	return strconv.Itoa(daily.GameID), daily.Date, nil
+}
+
+func printDailyLeaderboard(date string) {
+	resp, err := http.Get(fmt.Sprintf("http://%s/daily/leaderboard?date=%s", server_host, date))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer resp.Body.Close()
+	scores := []dailyScore{}
+	if err := json.NewDecoder(resp.Body).Decode(&scores); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("Leaderboard of %s:\n", date)
+	for i, s := range scores {
+		fmt.Printf("%d. %s: %d\n", i+1, s.Player, s.Score)
+	}
+}
+
+func (game *Game) initGame(socket_url string, player string, opponent string, locale string) {
+	game.connectToSocket(socket_url, player, opponent, locale)
+}
+
+func (game *Game) connectToSocket(url string, player string, opponent string, locale string) {
 	header := make(http.Header)
 	var Dialer websocket.Dialer
 
 	header.Add("Origin", " http://localhost:3434")
 	header.Add("userID", player)
 	header.Add("gameID", opponent)
+	header.Add("locale", locale)
 
 	conn, resp, err := Dialer.Dial(url, header)
 	if err != nil {
@@ -105,9 +663,9 @@ func checkSocket(conn *websocket.Conn) {
 		case "acknowledged":
 			fmt.Printf("%s \n", m.Content)
 		case "timeout":
-			fmt.Printf("%s \n", m.Content)
+			printStatus(m.Content)
 		case "question":
-			ans := playQuestion(m.Content)
+			ans := playQuestion(m.Content, conn)
 			resp := message{"answer", ans}
 			conn.WriteJSON(resp)
 		case "scoreUpdate":
@@ -116,9 +674,10 @@ func checkSocket(conn *websocket.Conn) {
 			//fmt.Print("Scores :")
 			//fmt.Println(scores)
 		case "status":
-			status := status{}
-			mapstructure.Decode(m.Content, &status)
-			fmt.Println(status.Message)
+			printStatus(m.Content)
+		case "hint":
+			// the answer went out before this hint came back
+			printHint(m.Content)
 		case "gameOver":
 			g := map[string]int{}
 			mapstructure.Decode(m.Content, &g)
@@ -141,30 +700,53 @@ func checkSocket(conn *websocket.Conn) {
 
 
 func main() {
+	flag.Parse()
 
 	username_promt := promptui.Prompt{
 		Label: "Input your username",
 	}
 	gameID_prompt := promptui.Prompt{
-		Label: "Enter ID of game room",
+		Label: "Enter ID of game room, \"daily\" for today's challenge, or nothing to practice alone",
+	}
+	locale_prompt := promptui.Prompt{
+		Label:   "Language for country names (en, de, es, fr)",
+		Default: "en",
 	}
 
 	player, err := username_promt.Run()
 	gameID, err := gameID_prompt.Run()
+	locale, err := locale_prompt.Run()
 
 
 	player_username = player
 	opponent_username = "John"
 
-	if player == "" || gameID == "" {
+	if player == "" {
 		fmt.Println("Please input valid user names")
 		return
 	}
+	dailyDate := ""
+	if gameID == "" {
+		gameID, err = startPractice(player)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+	} else if gameID == "daily" {
+		gameID, dailyDate, err = startDaily(player)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
 
 	game := Game{}
 	fmt.Println("Starting game...🕹")
-	game.initGame("ws://ee60a3ab.ngrok.io/ws", player, gameID)
+	game.initGame(fmt.Sprintf("ws://%s/ws", server_host), player, gameID, locale)
 	checkSocket(game.Conn)
+	if dailyDate != "" {
+		printDailyLeaderboard(dailyDate)
+	}
 
 	if err != nil {
 		fmt.Printf("Prompt failed %v\n", err)
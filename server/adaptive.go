@@ -0,0 +1,70 @@
+package main
+
+import "sync"
+
+// answerStats tracks how well a player does on each country and subregion
+// during a game, so that their next questions can dwell on their weak spots.
+type answerStats struct {
+	mux        sync.Mutex
+	countries  map[string]*performance
+	subregions map[string]*performance
+}
+
+// performance sums the strength of a player's answers, each from 0 for a
+// wrong or missing answer to 1 for a right and instant one.
+type performance struct {
+	answers  int
+	strength float64
+}
+
+func newAnswerStats() *answerStats {
+	return &answerStats{
+		countries:  make(map[string]*performance),
+		subregions: make(map[string]*performance),
+	}
+}
+
+// record counts an answer about c. Slow answers count as half right at most.
+func (s *answerStats) record(c country, credit float64, fractionOfTime float64) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	strength := credit * (1 - fractionOfTime/2)
+	for key, stats := range map[string]map[string]*performance{c.Alpha3: s.countries, c.Subregion: s.subregions} {
+		p, ok := stats[key]
+		if !ok {
+			p = &performance{}
+			stats[key] = p
+		}
+		p.answers++
+		p.strength += strength
+	}
+}
+
+// weight rates how much c is worth asking about: the weaker the player has
+// been on it and its subregion, the higher. Subregions not asked about yet
+// sit in the middle, so they still come up.
+func (s *answerStats) weight(c country) float64 {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	weight := 1 + 3*(1-s.subregions[c.Subregion].average())
+	if p, ok := s.countries[c.Alpha3]; ok {
+		weight += 3 * (1 - p.average())
+	}
+	return weight
+}
+
+// revisit tells whether the player did badly on c, so it's worth asking about
+// again even though they've seen it.
+func (s *answerStats) revisit(c country) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	p, ok := s.countries[c.Alpha3]
+	return ok && p.average() < 0.5
+}
+
+func (p *performance) average() float64 {
+	if p == nil || p.answers == 0 {
+		return 0.5
+	}
+	return p.strength / float64(p.answers)
+}
@@ -0,0 +1,50 @@
+package main
+
+import "testing"
+
+func TestMissedCountryComesBack(t *testing.T) {
+	countries := []country{
+		{Alpha3: "AAA", Subregion: "North"},
+		{Alpha3: "BBB", Subregion: "North"},
+		{Alpha3: "CCC", Subregion: "North"},
+		{Alpha3: "DDD", Subregion: "North"},
+	}
+	stats := newAnswerStats()
+	pool, err := newCountryPool(countries, 2, EasyDifficulty, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.weight = stats.weight
+	pool.revisit = stats.revisit
+	stats.record(countries[0], 0, 1)
+	stats.record(countries[1], 1, 0)
+	stats.record(countries[2], 1, 0)
+
+	picks := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		pool.asked = map[string]bool{"AAA": true, "BBB": true, "CCC": true}
+		picks[pool.pick().Alpha3]++
+	}
+	if picks["BBB"] > 0 || picks["CCC"] > 0 {
+		t.Errorf("countries answered right were asked again: %v", picks)
+	}
+	if picks["AAA"] <= picks["DDD"] {
+		t.Errorf("missed country should come up more often than the fresh one: %v", picks)
+	}
+}
+
+func TestRightAnswerStopsRevisit(t *testing.T) {
+	c := country{Alpha3: "AAA", Subregion: "North"}
+	stats := newAnswerStats()
+	if stats.revisit(c) {
+		t.Error("unseen country is revisited")
+	}
+	stats.record(c, 0, 1)
+	if !stats.revisit(c) {
+		t.Error("missed country isn't revisited")
+	}
+	stats.record(c, 1, 0)
+	if stats.revisit(c) {
+		t.Error("country answered right since is still revisited")
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+const (
+	BordersKind     = "borders"
+	BorderCountKind = "borderCount"
+)
+
+// bordersSource asks which of the options borders a country.
+type bordersSource struct {
+	pool *countryPool
+}
+
+// borderCountSource asks how many countries border a country.
+type borderCountSource struct {
+	pool *countryPool
+}
+
+// subjects accepts the countries with a neighbor in the pool, and enough
+// countries they don't border to fill the other options.
+func (s *bordersSource) subjects() func(country) bool {
+	byCode := s.pool.byCode()
+	return func(c country) bool {
+		return len(neighborsIn(c, byCode)) > 0 && s.pool.canFill(c, c, nameOf, func(other country) bool {
+			return borders(c, other)
+		})
+	}
+}
+
+func (s *bordersSource) askable() bool {
+	return s.pool.has(s.subjects())
+}
+
+func (s *bordersSource) Next() (question, Validator) {
+	subject, ok := s.pool.pickWhere(s.subjects())
+	if !ok {
+		// ruled out by askable, kept as a guard
+		return (&capitalSource{s.pool}).Next()
+	}
+	candidates := neighborsIn(subject, s.pool.byCode())
+	answer := candidates[s.pool.rng.Intn(len(candidates))]
+	picked := s.pool.withDistractors(subject, answer, nameOf, func(c country) bool {
+		return borders(subject, c)
+	})
+	q := question{
+		Id:      uuid.New().String(),
+		Kind:    BordersKind,
+		Country: subject.Name,
+		subject: subject.Alpha3,
+	}
+	return q, optionValidator{q.setCountryOptions(picked, NameField, answer), answer.Name}
+}
+
+func (s *borderCountSource) Next() (question, Validator) {
+	subject, ok := s.pool.pickWhere(func(c country) bool {
+		return len(c.Neighbors) > 0
+	})
+	if !ok {
+		subject = s.pool.pick()
+	}
+	count := len(subject.Neighbors)
+	counts := []int{count}
+	used := map[int]bool{count: true}
+	for spread := 3; len(counts) < s.pool.options; spread++ {
+		guess := count - spread + s.pool.rng.Intn(2*spread+1)
+		if guess < 0 || used[guess] {
+			continue
+		}
+		used[guess] = true
+		counts = append(counts, guess)
+	}
+	sort.Ints(counts)
+	options := make([]string, len(counts))
+	for i, c := range counts {
+		options[i] = strconv.Itoa(c)
+	}
+	q := question{
+		Id:      uuid.New().String(),
+		Kind:    BorderCountKind,
+		Country: subject.Name,
+		subject: subject.Alpha3,
+	}
+	return q, optionValidator{q.setOptions(options, strconv.Itoa(count)), strconv.Itoa(count)}
+}
+
+// neighborsIn returns the neighbors of c found in byCode.
+func neighborsIn(c country, byCode map[string]country) []country {
+	var neighbors []country
+	for _, code := range c.Neighbors {
+		if neighbor, found := byCode[code]; found {
+			neighbors = append(neighbors, neighbor)
+		}
+	}
+	return neighbors
+}
+
+func borders(a country, b country) bool {
+	for _, neighbor := range a.Neighbors {
+		if neighbor == b.Alpha3 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+const CompareKind = "compare"
+
+var compareMetrics = []string{"population", "area", "density"}
+
+// compareSource shows two countries and asks which has the larger
+// population, area or density.
+type compareSource struct {
+	pool *countryPool
+}
+
+// compareValidator grades comparisons and reveals the figures compared.
+type compareValidator struct {
+	optionValidator
+	first  country
+	second country
+	metric string
+}
+
+// subjects accepts the countries that can be compared on metric with another
+// one of the pool.
+func (s *compareSource) subjects(metric string) func(country) bool {
+	return func(c country) bool {
+		return measure(c, metric) > 0 && len(s.rivals(c, metric)) > 0
+	}
+}
+
+// askable tells whether every metric has countries to compare, since any of
+// them may be asked.
+func (s *compareSource) askable() bool {
+	for _, metric := range compareMetrics {
+		if !s.pool.has(s.subjects(metric)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *compareSource) Next() (question, Validator) {
+	metric := compareMetrics[s.pool.rng.Intn(len(compareMetrics))]
+	first, ok := s.pool.pickWhere(s.subjects(metric))
+	if !ok {
+		// ruled out by askable, kept as a guard
+		return (&capitalSource{s.pool}).Next()
+	}
+	rivals := s.rivals(first, metric)
+	second := rivals[s.pool.rng.Intn(len(rivals))]
+	larger := first
+	if measure(second, metric) > measure(first, metric) {
+		larger = second
+	}
+	q := question{
+		Id:     uuid.New().String(),
+		Kind:   CompareKind,
+		Metric: metric,
+		// explained and recorded as a question about the larger country
+		subject: larger.Alpha3,
+	}
+	answerId := q.setCountryOptions([]country{first, second}, NameField, larger)
+	return q, compareValidator{optionValidator{answerId, larger.Name}, first, second, metric}
+}
+
+// rivals are the countries first can be compared with. Harder games compare
+// countries of the same region with closer figures.
+func (s *compareSource) rivals(first country, metric string) []country {
+	var candidates []country
+	for _, c := range s.pool.countries {
+		if c.Alpha3 == first.Alpha3 || measure(c, metric) <= 0 || measure(c, metric) == measure(first, metric) {
+			continue
+		}
+		if s.pool.difficulty != EasyDifficulty && c.Continent != first.Continent {
+			continue
+		}
+		candidates = append(candidates, c)
+	}
+	if s.pool.difficulty == HardDifficulty {
+		gap := func(c country) float64 {
+			return math.Abs(math.Log(measure(c, metric) / measure(first, metric)))
+		}
+		sort.Slice(candidates, func(i, j int) bool { return gap(candidates[i]) < gap(candidates[j]) })
+		if len(candidates) > 5 {
+			candidates = candidates[:5]
+		}
+	}
+	return candidates
+}
+
+func measure(c country, metric string) float64 {
+	switch metric {
+	case "population":
+		return float64(c.Population)
+	case "area":
+		return c.Area
+	default:
+		if c.Area == 0 {
+			return 0
+		}
+		return float64(c.Population) / c.Area
+	}
+}
+
+func formatMeasure(c country, metric string) string {
+	switch metric {
+	case "population":
+		return formatInt(c.Population) + " people"
+	case "area":
+		return formatInt(int(math.Round(c.Area))) + " km²"
+	default:
+		return fmt.Sprintf("%.1f people per km²", measure(c, metric))
+	}
+}
+
+func (v compareValidator) Reveal(locale string) string {
+	return fmt.Sprintf("%s: %s, %s: %s",
+		v.first.text(NameField, locale), formatMeasure(v.first, v.metric),
+		v.second.text(NameField, locale), formatMeasure(v.second, v.metric))
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+)
+
+var continents = map[string]bool{
+	"Africa":        true,
+	"Antarctica":    true,
+	"Asia":          true,
+	"Europe":        true,
+	"North America": true,
+	"Oceania":       true,
+	"South America": true,
+}
+
+var alpha2Pattern = regexp.MustCompile(`^[A-Z]{2}$`)
+var alpha3Pattern = regexp.MustCompile(`^[A-Z]{3}$`)
+var localePattern = regexp.MustCompile(`^[a-z]{2}$`)
+
+type country struct {
+	Name    string `json:"name"`
+	Capital string `json:"capital"`
+	// CapitalAltSpellings are other accepted ways of writing the capital.
+	CapitalAltSpellings []string `json:"capitalAltSpellings"`
+	Alpha2              string   `json:"alpha2"`
+	Alpha3              string   `json:"alpha3"`
+	Continent           string   `json:"continent"`
+	Subregion           string   `json:"subregion"`
+	// Sovereign is set for members and observers of the United Nations.
+	Sovereign           bool     `json:"sovereign"`
+	Population          int      `json:"population"`
+	Area                float64  `json:"area"`
+	Lat                 float64  `json:"lat"`
+	Lon                 float64  `json:"lon"`
+	CapitalLat          float64  `json:"capitalLat"`
+	CapitalLon          float64  `json:"capitalLon"`
+	Neighbors           []string `json:"neighbors"`
+	Currencies          []string `json:"currencies"`
+	Languages           []string `json:"languages"`
+	AltSpellings        []string `json:"altSpellings"`
+	// OtherCapitals are accepted too, for countries whose seat of government
+	// is split between several cities.
+	OtherCapitals []string `json:"otherCapitals"`
+	// Translations holds the name and capital in other languages, keyed by
+	// locale. Whatever is missing falls back to English.
+	Translations map[string]translation `json:"translations"`
+}
+
+type translation struct {
+	Name          string   `json:"name"`
+	Capital       string   `json:"capital"`
+	OtherCapitals []string `json:"otherCapitals"`
+}
+
+// capitals returns every capital of the country, the main one first.
+func (c country) capitals() []string {
+	return append([]string{c.Capital}, c.OtherCapitals...)
+}
+
+// hasCapital reports whether capital is one of the country's capitals.
+func (c country) hasCapital(capital string) bool {
+	for _, candidate := range c.capitals() {
+		if normalize(candidate) == normalize(capital) {
+			return true
+		}
+	}
+	return false
+}
+
+// countryError describes why an entry of a dataset can't be used for questions.
+type countryError struct {
+	Index   int
+	Name    string
+	Problem string
+}
+
+func (e countryError) Error() string {
+	return fmt.Sprintf("entry %d (%s): %s", e.Index, e.Name, e.Problem)
+}
+
+// loadCountries reads the dataset at p and returns the entries that passed
+// validation along with a countryError for each problem found.
+func loadCountries(p string) ([]country, []error, error) {
+	data, err := ioutil.ReadFile(p)
+	if err != nil {
+		return nil, nil, err
+	}
+	var countries []country
+	if err := json.Unmarshal(data, &countries); err != nil {
+		return nil, nil, fmt.Errorf("parsing %s: %v", p, err)
+	}
+	valid, problems := validateCountries(countries)
+	return valid, problems, nil
+}
+
+// validateCountries drops every entry with at least one problem.
+func validateCountries(countries []country) ([]country, []error) {
+	var problems []error
+	codes := make(map[string]bool)
+	for _, c := range countries {
+		codes[c.Alpha3] = true
+	}
+	seen := make(map[string]bool)
+	valid := make([]country, 0, len(countries))
+	for i, c := range countries {
+		entryProblems := checkCountry(c, codes)
+		for _, key := range []string{"name " + c.Name, "alpha2 " + c.Alpha2, "alpha3 " + c.Alpha3} {
+			if seen[key] {
+				entryProblems = append(entryProblems, "duplicate "+key)
+			}
+			seen[key] = true
+		}
+		for _, problem := range entryProblems {
+			problems = append(problems, countryError{i, c.Name, problem})
+		}
+		if len(entryProblems) == 0 {
+			valid = append(valid, c)
+		}
+	}
+	return valid, problems
+}
+
+func checkCountry(c country, codes map[string]bool) []string {
+	var problems []string
+	if c.Name == "" {
+		problems = append(problems, "empty name")
+	}
+	if c.Capital == "" {
+		problems = append(problems, "empty capital")
+	}
+	for _, capital := range c.OtherCapitals {
+		if capital == "" || capital == c.Capital {
+			problems = append(problems, fmt.Sprintf("invalid other capital %q", capital))
+		}
+	}
+	if !alpha2Pattern.MatchString(c.Alpha2) {
+		problems = append(problems, fmt.Sprintf("invalid alpha2 code %q", c.Alpha2))
+	}
+	if !alpha3Pattern.MatchString(c.Alpha3) {
+		problems = append(problems, fmt.Sprintf("invalid alpha3 code %q", c.Alpha3))
+	}
+	if !continents[c.Continent] {
+		problems = append(problems, fmt.Sprintf("unknown continent %q", c.Continent))
+	}
+	if c.Subregion == "" {
+		problems = append(problems, "empty subregion")
+	}
+	// comparisons and orderings divide and take logarithms of these
+	if c.Population < 0 {
+		problems = append(problems, "negative population")
+	} else if c.Population == 0 {
+		problems = append(problems, "missing population")
+	}
+	if c.Area < 0 {
+		problems = append(problems, "negative area")
+	} else if c.Area == 0 {
+		problems = append(problems, "missing area")
+	}
+	// a missing position decodes to 0,0, in the open sea off Africa
+	if c.Lat == 0 && c.Lon == 0 {
+		problems = append(problems, "missing coordinates")
+	}
+	if c.CapitalLat == 0 && c.CapitalLon == 0 {
+		problems = append(problems, "missing capital coordinates")
+	}
+	if c.Lat < -90 || c.Lat > 90 || c.Lon < -180 || c.Lon > 180 {
+		problems = append(problems, fmt.Sprintf("coordinates %v,%v out of range", c.Lat, c.Lon))
+	}
+	if c.CapitalLat < -90 || c.CapitalLat > 90 || c.CapitalLon < -180 || c.CapitalLon > 180 {
+		problems = append(problems, fmt.Sprintf("capital coordinates %v,%v out of range", c.CapitalLat, c.CapitalLon))
+	}
+	for _, neighbor := range c.Neighbors {
+		if neighbor == c.Alpha3 || !codes[neighbor] {
+			problems = append(problems, fmt.Sprintf("unknown neighbor %q", neighbor))
+		}
+	}
+	for locale := range c.Translations {
+		if !localePattern.MatchString(locale) {
+			problems = append(problems, fmt.Sprintf("invalid translation locale %q", locale))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheckCountry(t *testing.T) {
+	valid := country{
+		Name:       "Kenya",
+		Capital:    "Nairobi",
+		Alpha2:     "KE",
+		Alpha3:     "KEN",
+		Continent:  "Africa",
+		Subregion:  "Eastern Africa",
+		Population: 51393010,
+		Area:       580367,
+		Lat:        1,
+		Lon:        38,
+		CapitalLat: -1.28,
+		CapitalLon: 36.82,
+	}
+	tests := []struct {
+		name     string
+		change   func(c *country)
+		problems []string
+	}{
+		{"valid", func(c *country) {}, nil},
+		{"on the equator", func(c *country) { c.Lat = 0 }, nil},
+		{"no subregion", func(c *country) { c.Subregion = "" }, []string{"empty subregion"}},
+		{"no population", func(c *country) { c.Population = 0 }, []string{"missing population"}},
+		{"negative area", func(c *country) { c.Area = -1 }, []string{"negative area"}},
+		{"no area", func(c *country) { c.Area = 0 }, []string{"missing area"}},
+		{"no coordinates", func(c *country) { c.Lat, c.Lon = 0, 0 }, []string{"missing coordinates"}},
+		{"no capital coordinates", func(c *country) { c.CapitalLat, c.CapitalLon = 0, 0 }, []string{"missing capital coordinates"}},
+	}
+	for _, test := range tests {
+		c := valid
+		test.change(&c)
+		if problems := checkCountry(c, map[string]bool{"KEN": true}); !reflect.DeepEqual(problems, test.problems) {
+			t.Errorf("%s: got %q, want %q", test.name, problems, test.problems)
+		}
+	}
+}
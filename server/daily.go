@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	DAILY_ROUNDS     = 10
+	DAILY_DIFFICULTY = MediumDifficulty
+)
+
+var errPlayedToday = errors.New("already played today's challenge, come back tomorrow")
+
+type DailyRequest struct {
+	Player string `json:"player"`
+}
+
+type DailyResponse struct {
+	GameID int    `json:"gameID"`
+	Date   string `json:"date"`
+}
+
+type dailyScore struct {
+	Player string `json:"player"`
+	Score  int    `json:"score"`
+}
+
+// dailyStore keeps the score of everyone who took each day's challenge,
+// saved to a JSON file. Players are entered when they join their game, so
+// that leaving halfway doesn't earn them another try.
+type dailyStore struct {
+	mux  sync.Mutex
+	path string
+	days map[string]map[string]int
+}
+
+func loadDailyStore(p string) (*dailyStore, error) {
+	store := &dailyStore{path: p, days: make(map[string]map[string]int)}
+	if err := readJSONFile(p, &store.days); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// today is the date of the current challenge. Days change at midnight UTC,
+// the same moment for every player.
+func today() string {
+	return time.Now().UTC().Format(DATE_LAYOUT)
+}
+
+// dailySeed derives the seed of the questions of date, so that everyone
+// playing that day gets the same ones.
+func dailySeed(date string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("daily " + date))
+	// kept below 2^53 like the seeds of other games
+	return int64(h.Sum64() & (1<<53 - 1))
+}
+
+// played tells whether player already took date's challenge.
+func (s *dailyStore) played(date string, player string) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	_, played := s.days[date][player]
+	return played
+}
+
+// start enters player in date's challenge, unless they already took it.
+func (s *dailyStore) start(date string, player string) bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.days[date] == nil {
+		s.days[date] = make(map[string]int)
+	}
+	if _, played := s.days[date][player]; played {
+		return false
+	}
+	s.days[date][player] = 0
+	s.save()
+	return true
+}
+
+func (s *dailyStore) finish(date string, player string, score int) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.days[date][player] = score
+	s.save()
+}
+
+// leaderboard ranks the players of date's challenge, best first.
+func (s *dailyStore) leaderboard(date string) []dailyScore {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	scores := make([]dailyScore, 0, len(s.days[date]))
+	for player, score := range s.days[date] {
+		scores = append(scores, dailyScore{player, score})
+	}
+	sort.Slice(scores, func(i int, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		return scores[i].Player < scores[j].Player
+	})
+	return scores
+}
+
+// save writes the store. The caller holds the lock.
+func (s *dailyStore) save() {
+	if err := writeJSONFile(s.path, s.days); err != nil {
+		fmt.Println("Error saving daily challenge:", err)
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+)
+
+// explanation tells players more about the country a question was about,
+// once they have answered.
+type explanation struct {
+	Country    string `json:"country"`
+	Flag       string `json:"flag"`
+	Population int    `json:"population"`
+	Region     string `json:"region"`
+	Fact       string `json:"fact,omitempty"`
+}
+
+// explain returns the explanation of q in locale, or nil when q isn't about a
+// country. The fact is picked from the question's ID, so every player of a
+// game reads the same one.
+func explain(q question, locale string) *explanation {
+	c, ok := countriesByCode[q.subject]
+	if !ok {
+		return nil
+	}
+	e := &explanation{
+		Country:    c.text(NameField, locale),
+		Flag:       flagEmoji(c.Alpha2),
+		Population: c.Population,
+		Region:     regionName(c.Subregion, locale),
+	}
+	if facts := c.facts(locale); len(facts) > 0 {
+		h := fnv.New32a()
+		h.Write([]byte(q.Id))
+		e.Fact = facts[h.Sum32()%uint32(len(facts))]
+	}
+	return e
+}
+
+// factTexts are the sentences of the facts in each locale. The dataset only
+// names languages and currencies in English, so those facts are English only.
+var factTexts = map[string]map[string]string{
+	DEFAULT_LOCALE: {
+		"and":        "and",
+		"capitals":   "It has %d capitals: %s.",
+		"languages":  "People there speak %s.",
+		"noBorders":  "It has no land borders.",
+		"neighbor":   "Its only neighbor is %s.",
+		"neighbors":  "It borders %d countries: %s.",
+		"area":       "It covers %s km², with %.1f people per km².",
+		"currencies": "It pays in %s.",
+		"territory":  "It's a territory rather than a sovereign state.",
+	},
+	"de": {
+		"and":       "und",
+		"capitals":  "Es hat %d Hauptstädte: %s.",
+		"noBorders": "Es hat keine Landgrenzen.",
+		"neighbor":  "Sein einziger Nachbar ist %s.",
+		"neighbors": "Es grenzt an %d Länder: %s.",
+		"area":      "Es umfasst %s km², mit %.1f Einwohnern pro km².",
+		"territory": "Es ist ein abhängiges Gebiet und kein souveräner Staat.",
+	},
+	"es": {
+		"and":       "y",
+		"capitals":  "Tiene %d capitales: %s.",
+		"noBorders": "No tiene fronteras terrestres.",
+		"neighbor":  "Su único vecino es %s.",
+		"neighbors": "Limita con %d países: %s.",
+		"area":      "Ocupa %s km², con %.1f habitantes por km².",
+		"territory": "Es un territorio, no un estado soberano.",
+	},
+	"fr": {
+		"and":       "et",
+		"capitals":  "Il a %d capitales : %s.",
+		"noBorders": "Il n'a aucune frontière terrestre.",
+		"neighbor":  "Son seul voisin est %s.",
+		"neighbors": "Il est voisin de %d pays : %s.",
+		"area":      "Il couvre %s km², avec %.1f habitants par km².",
+		"territory": "C'est un territoire et non un État souverain.",
+	},
+}
+
+// regionNames translates the subregions of the dataset.
+var regionNames = map[string]map[string]string{
+	"de": {
+		"Antarctica":                "Antarktis",
+		"Australia and New Zealand": "Australien und Neuseeland",
+		"Caribbean":                 "Karibik",
+		"Central America":           "Mittelamerika",
+		"Central Asia":              "Zentralasien",
+		"Eastern Africa":            "Ostafrika",
+		"Eastern Asia":              "Ostasien",
+		"Eastern Europe":            "Osteuropa",
+		"Melanesia":                 "Melanesien",
+		"Micronesia":                "Mikronesien",
+		"Middle Africa":             "Zentralafrika",
+		"Northern Africa":           "Nordafrika",
+		"Northern America":          "Nordamerika",
+		"Northern Europe":           "Nordeuropa",
+		"Polynesia":                 "Polynesien",
+		"South America":             "Südamerika",
+		"South-Eastern Asia":        "Südostasien",
+		"Southern Africa":           "Südliches Afrika",
+		"Southern Asia":             "Südasien",
+		"Southern Europe":           "Südeuropa",
+		"Western Africa":            "Westafrika",
+		"Western Asia":              "Westasien",
+		"Western Europe":            "Westeuropa",
+	},
+	"es": {
+		"Antarctica":                "Antártida",
+		"Australia and New Zealand": "Australia y Nueva Zelanda",
+		"Caribbean":                 "Caribe",
+		"Central America":           "América Central",
+		"Central Asia":              "Asia Central",
+		"Eastern Africa":            "África Oriental",
+		"Eastern Asia":              "Asia Oriental",
+		"Eastern Europe":            "Europa Oriental",
+		"Melanesia":                 "Melanesia",
+		"Micronesia":                "Micronesia",
+		"Middle Africa":             "África Central",
+		"Northern Africa":           "África del Norte",
+		"Northern America":          "América del Norte",
+		"Northern Europe":           "Europa del Norte",
+		"Polynesia":                 "Polinesia",
+		"South America":             "América del Sur",
+		"South-Eastern Asia":        "Sudeste Asiático",
+		"Southern Africa":           "África Austral",
+		"Southern Asia":             "Asia del Sur",
+		"Southern Europe":           "Europa del Sur",
+		"Western Africa":            "África Occidental",
+		"Western Asia":              "Asia Occidental",
+		"Western Europe":            "Europa Occidental",
+	},
+	"fr": {
+		"Antarctica":                "Antarctique",
+		"Australia and New Zealand": "Australie et Nouvelle-Zélande",
+		"Caribbean":                 "Caraïbes",
+		"Central America":           "Amérique centrale",
+		"Central Asia":              "Asie centrale",
+		"Eastern Africa":            "Afrique de l'Est",
+		"Eastern Asia":              "Asie de l'Est",
+		"Eastern Europe":            "Europe de l'Est",
+		"Melanesia":                 "Mélanésie",
+		"Micronesia":                "Micronésie",
+		"Middle Africa":             "Afrique centrale",
+		"Northern Africa":           "Afrique du Nord",
+		"Northern America":          "Amérique du Nord",
+		"Northern Europe":           "Europe du Nord",
+		"Polynesia":                 "Polynésie",
+		"South America":             "Amérique du Sud",
+		"South-Eastern Asia":        "Asie du Sud-Est",
+		"Southern Africa":           "Afrique australe",
+		"Southern Asia":             "Asie du Sud",
+		"Southern Europe":           "Europe du Sud",
+		"Western Africa":            "Afrique de l'Ouest",
+		"Western Asia":              "Asie de l'Ouest",
+		"Western Europe":            "Europe de l'Ouest",
+	},
+}
+
+// regionName returns region in locale, falling back to English when there's
+// no translation.
+func regionName(region string, locale string) string {
+	if name, ok := regionNames[locale][region]; ok {
+		return name
+	}
+	return region
+}
+
+// facts are short sentences about c drawn from the dataset, written in
+// locale. Facts without a translation are left out rather than mixed in.
+func (c country) facts(locale string) []string {
+	texts, ok := factTexts[locale]
+	if !ok {
+		texts = factTexts[DEFAULT_LOCALE]
+	}
+	var facts []string
+	add := func(key string, args ...interface{}) {
+		if format, ok := texts[key]; ok {
+			facts = append(facts, fmt.Sprintf(format, args...))
+		}
+	}
+	if len(c.OtherCapitals) > 0 {
+		capitals := append([]string{c.text(CapitalField, locale)}, c.OtherCapitals...)
+		add("capitals", len(capitals), listItems(capitals, texts["and"]))
+	}
+	if len(c.Languages) > 0 {
+		add("languages", listItems(c.Languages, texts["and"]))
+	}
+	var neighbors []string
+	for _, code := range c.Neighbors {
+		if neighbor, ok := countriesByCode[code]; ok {
+			neighbors = append(neighbors, neighbor.text(NameField, locale))
+		}
+	}
+	switch {
+	case len(neighbors) == 0:
+		add("noBorders")
+	case len(neighbors) == 1:
+		add("neighbor", neighbors[0])
+	default:
+		add("neighbors", len(neighbors), listItems(neighbors, texts["and"]))
+	}
+	if c.Area > 0 {
+		add("area", formatInt(int(math.Round(c.Area))), measure(c, "density"))
+	}
+	if len(c.Currencies) > 0 {
+		add("currencies", listItems(c.Currencies, texts["and"]))
+	}
+	if !c.Sovereign {
+		add("territory")
+	}
+	return facts
+}
+
+// listItems joins items as in "A, B and C", with and in the reader's
+// language.
+func listItems(items []string, and string) string {
+	if len(items) < 2 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " " + and + " " + items[len(items)-1]
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFactsInLocale(t *testing.T) {
+	countriesByCode = map[string]country{
+		"DEU": {Name: "Germany", Alpha3: "DEU", Capital: "Berlin", Subregion: "Western Europe", Sovereign: true,
+			Neighbors: []string{"AUT", "CHE"}, Languages: []string{"German"}, Currencies: []string{"Euro"},
+			Translations: map[string]translation{"de": {Name: "Deutschland"}, "es": {Name: "Alemania"}}},
+		"AUT": {Name: "Austria", Alpha3: "AUT", Translations: map[string]translation{"de": {Name: "Österreich"}}},
+		"CHE": {Name: "Switzerland", Alpha3: "CHE", Translations: map[string]translation{"de": {Name: "Schweiz"}, "es": {Name: "Suiza"}}},
+	}
+	tests := []struct {
+		locale string
+		region string
+		facts  []string
+	}{
+		{DEFAULT_LOCALE, "Western Europe", []string{"People there speak German.", "It borders 2 countries: Austria and Switzerland.", "It pays in Euro."}},
+		{"de", "Westeuropa", []string{"Es grenzt an 2 Länder: Österreich und Schweiz."}},
+		{"es", "Europa Occidental", []string{"Limita con 2 países: Austria y Suiza."}},
+		{"pt", "Western Europe", []string{"People there speak German.", "It borders 2 countries: Austria and Switzerland.", "It pays in Euro."}},
+	}
+	for _, test := range tests {
+		e := explain(question{Id: "q1", subject: "DEU"}, test.locale)
+		if e.Region != test.region {
+			t.Errorf("%s: region %q, want %q", test.locale, e.Region, test.region)
+		}
+		if facts := countriesByCode["DEU"].facts(test.locale); !reflect.DeepEqual(facts, test.facts) {
+			t.Errorf("%s: facts %q, want %q", test.locale, facts, test.facts)
+		}
+	}
+}
+
+func TestCompareQuestionsAreExplained(t *testing.T) {
+	fetchCapitals("assets/countries.json")
+	pool, err := newCountryPool(capitals, DEFAULT_OPTIONS, MediumDifficulty, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := &compareSource{pool}
+	for i := 0; i < 20; i++ {
+		q, _ := source.Next()
+		e := explain(q, DEFAULT_LOCALE)
+		if e == nil {
+			t.Fatalf("%v: no explanation", q.Options)
+		}
+		if e.Country != q.Options[0] && e.Country != q.Options[1] {
+			t.Errorf("%v: explains %s", q.Options, e.Country)
+		}
+	}
+}
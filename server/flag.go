@@ -0,0 +1,107 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const FlagKind = "flag"
+
+// flagsData maps alpha3 codes to the stripes of flags simple enough to be
+// drawn as colored blocks. Emblems are left out, so colors come from a shared
+// palette and flags that would look the same have equal stripes.
+//
+//go:embed assets/flags.json
+var flagsData []byte
+
+var flagStripesByCode map[string]flagStripes
+
+// flagStripes are the colors of a flag's stripes, from the top or the left.
+type flagStripes struct {
+	Direction string   `json:"direction"`
+	Colors    []string `json:"colors"`
+}
+
+// flagSource shows a flag and asks which country it belongs to.
+type flagSource struct {
+	pool *countryPool
+}
+
+func fetchFlags() {
+	parsed := make(map[string]flagStripes)
+	if err := json.Unmarshal(flagsData, &parsed); err != nil {
+		panic(fmt.Errorf("parsing flags: %v", err))
+	}
+	flagStripesByCode = parsed
+}
+
+// Next only asks about flags that can be drawn as stripes, so that players
+// without emoji can answer too. Countries whose stripes look the same aren't
+// offered together.
+func (s *flagSource) Next() (question, Validator) {
+	answer, ok := s.pool.pickWhere(s.subjects)
+	if !ok {
+		// ruled out by askable, kept as a guard
+		return (&capitalSource{s.pool}).Next()
+	}
+	picked := s.pool.withDistractors(answer, answer, nameOf, lookalike(answer))
+	stripes := flagStripesByCode[answer.Alpha3]
+	q := question{
+		Id:      uuid.New().String(),
+		Kind:    FlagKind,
+		Flag:    flagEmoji(answer.Alpha2),
+		Stripes: &stripes,
+		subject: answer.Alpha3,
+	}
+	return q, optionValidator{q.setCountryOptions(picked, NameField, answer), answer.Name}
+}
+
+func (s *flagSource) askable() bool {
+	return s.pool.has(s.subjects)
+}
+
+// subjects accepts the countries with stripes and enough other flags to fill
+// the options.
+func (s *flagSource) subjects(c country) bool {
+	return hasStripes(c) && s.pool.canFill(c, c, nameOf, lookalike(c))
+}
+
+func hasStripes(c country) bool {
+	_, found := flagStripesByCode[c.Alpha3]
+	return found
+}
+
+// lookalike accepts the countries whose stripes look like answer's.
+func lookalike(answer country) func(country) bool {
+	stripes := flagStripesByCode[answer.Alpha3]
+	return func(c country) bool {
+		other, found := flagStripesByCode[c.Alpha3]
+		return found && other.equals(stripes)
+	}
+}
+
+// flagEmoji spells alpha2 in regional indicator symbols, which terminals
+// with emoji support show as the flag.
+func flagEmoji(alpha2 string) string {
+	var emoji strings.Builder
+	for _, letter := range strings.ToUpper(alpha2) {
+		emoji.WriteRune(0x1F1E6 + letter - 'A')
+	}
+	return emoji.String()
+}
+
+func (f flagStripes) equals(other flagStripes) bool {
+	if f.Direction != other.Direction || len(f.Colors) != len(other.Colors) {
+		return false
+	}
+	for i := range f.Colors {
+		if f.Colors[i] != other.Colors[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -5,11 +5,26 @@ import (
 	"fmt"
 	"github.com/mitchellh/mapstructure"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 )
 
 const QUESTION_TIMEOUT  = 30 * time.Second
+const INTERMISSION = 1 * time.Second
+const DEFAULT_OPTIONS = 4
+
+// Bounds of what games can be created with.
+const (
+	MIN_OPTIONS          = 2
+	MAX_OPTIONS          = 8
+	MIN_QUESTION_TIMEOUT = 5 * time.Second
+	MAX_QUESTION_TIMEOUT = 2 * time.Minute
+	MAX_INTERMISSION     = 30 * time.Second
+)
+
+// SOLO_JOIN_TIMEOUT is how long the player of a solo game has to join it.
+const SOLO_JOIN_TIMEOUT = 1 * time.Minute
 
 type Game struct {
 	Id              int
@@ -22,9 +37,38 @@ type Game struct {
 	JoinSemaphore   sync.WaitGroup
 	StopGame        chan bool
 	UnregisterGame  chan int
+	Questions       QuestionSource
+	// QuestionTimeout is how long players have to answer, Intermission the
+	// pause between questions.
+	QuestionTimeout time.Duration
+	Intermission    time.Duration
+	// PlayerQuestions is set for games adapting to each player, Recorders
+	// for those learning from their answers.
+	PlayerQuestions map[string]QuestionSource
+	Recorders       map[string]answerRecorder
+	// Finished is told the final scores, for games whose results outlive them.
+	Finished        func(scores map[string]int)
+	// Joining, when set, is asked before each player joins and can turn them
+	// away. JoinTimeout, when set, is how long players have to join before
+	// the game is called off.
+	Joining         func(playerID string) error
+	JoinTimeout     time.Duration
+	joinMux         sync.Mutex
+	abandoned       bool
+}
+
+// answerRecorder learns from a player's answers about a country.
+type answerRecorder interface {
+	record(c country, credit float64, fractionOfTime float64)
+}
+
+// askedQuestion is a question together with the validator grading it.
+type askedQuestion struct {
+	question  question
+	validator Validator
 }
 
-func (g *Game) New(numOfPlayers int, numOfRounds int, gameID int, unregisterGame chan int){
+func (g *Game) New(numOfPlayers int, numOfRounds int, gameID int, unregisterGame chan int, questions QuestionSource){
 	g.Id = gameID
 	g.Players = make([] Player, numOfPlayers)
 	g.NumberOfPlayers = numOfPlayers
@@ -35,6 +79,9 @@ func (g *Game) New(numOfPlayers int, numOfRounds int, gameID int, unregisterGame
 	g.JoinSemaphore = sync.WaitGroup{}
 	g.StopGame = make(chan bool, 2)
 	g.UnregisterGame = unregisterGame
+	g.Questions = questions
+	g.QuestionTimeout = QUESTION_TIMEOUT
+	g.Intermission = INTERMISSION
 	g.JoinSemaphore.Add(numOfPlayers)
 }
 
@@ -48,6 +95,36 @@ func (g *Game) addPlayer(player Player){
 	}
 }
 
+// waitForPlayers waits for everyone to join and reports whether they did.
+// When JoinTimeout runs out first, the game is called off.
+func (g *Game) waitForPlayers() bool {
+	joined := make(chan bool)
+	go func() {
+		g.JoinSemaphore.Wait()
+		close(joined)
+	}()
+	if g.JoinTimeout == 0 {
+		<-joined
+		return true
+	}
+	select {
+	case <-joined:
+		return true
+	case <-time.After(g.JoinTimeout):
+	}
+	g.joinMux.Lock()
+	defer g.joinMux.Unlock()
+	if g.OnlinePlayers == g.NumberOfPlayers {
+		// the last player made it just in time
+		return true
+	}
+	g.abandoned = true
+	for i := g.OnlinePlayers; i < g.NumberOfPlayers; i++ {
+		g.JoinSemaphore.Done()
+	}
+	return false
+}
+
 func (g *Game) play() {
 	defer g.finishGame()
 	for i := 0; i < g.NumberOfRounds; i++ {
@@ -55,15 +132,14 @@ func (g *Game) play() {
 		case <-g.StopGame:
 			return
 		default:
-			q, ans := generateQuestion(4)
-			g.playQuestion(q, ans)
+			g.playQuestion(g.nextQuestions())
 			scoreUpdate := message{
 				Type:    ScoreUpdate,
 				Content: g.scores,
 			}
 			g.sendMessageToAllPlayers(scoreUpdate)
 			if i < g.NumberOfRounds-1 {
-				time.Sleep(1 * time.Second)
+				time.Sleep(g.Intermission)
 			}
 		}
 
@@ -91,21 +167,41 @@ func (g *Game) sendMessageToAllPlayers(msg message){
 	}
 }
 
-func (g *Game) sendQuestionToAllPlayers(q question, answer string){
-	m := message{QUESTION, q}
+// nextQuestions draws the round's question for each player. They all get the
+// same one unless the game adapts to each of them. Their streams then share
+// the seed, the kinds of questions and the timeout, so scores stay comparable.
+func (g *Game) nextQuestions() map[string]askedQuestion {
+	asked := make(map[string]askedQuestion)
+	if g.PlayerQuestions == nil {
+		q, validator := g.Questions.Next()
+		for _, player := range g.Players{
+			asked[player.Id] = askedQuestion{q, validator}
+		}
+		return asked
+	}
+	for _, player := range g.Players{
+		q, validator := g.PlayerQuestions[player.Id].Next()
+		asked[player.Id] = askedQuestion{q, validator}
+	}
+	return asked
+}
+
+func (g *Game) sendQuestionToAllPlayers(questions map[string]askedQuestion){
 	for _, player := range g.Players{
-		err := player.sendJSON(m)
+		q, validator := questions[player.Id].question, questions[player.Id].validator
+		localized := q.localize(player.Locale)
+		err := player.sendJSON(message{QUESTION, localized})
 		if err != nil {
 			g.StopGame <- true
 			return
 		}
 		g.AnswerSemaphore.Add(1)
-		go g.waitForAnswers(player, q, answer, QUESTION_TIMEOUT)
+		go g.waitForAnswers(player, localized, validator)
 	}
 }
 
-func (g *Game) playQuestion(question question, answer string){
-	g.sendQuestionToAllPlayers(question, answer)
+func (g *Game) playQuestion(questions map[string]askedQuestion){
+	g.sendQuestionToAllPlayers(questions)
 	g.AnswerSemaphore.Wait()
 }
 
@@ -131,16 +227,25 @@ func (g *Game) finishGame() {
 	endMessage.Type = GAMEOVER
 	g.calculateLeaderbaord()
 	endMessage.Content = g.scores
+	if g.Finished != nil {
+		g.Finished(g.scores)
+	}
 	g.sendMessageToAllPlayers(endMessage)
 	g.stopReadingFromAllPlayers()
 	g.UnregisterGame <- g.Id
 	fmt.Println("Game has ended!")
 }
 
-func (g *Game) waitForAnswers(player Player, question question, rightAnswer string, ttl time.Duration) {
+// waitForAnswers gives the player the game's timeout to answer. Scores shrink
+// with the fraction of it they used, so games with longer timeouts aren't
+// easier to score in. Players may ask for hints before answering, while the
+// clock keeps running.
+func (g *Game) waitForAnswers(player Player, question question, validator Validator) {
 	defer g.AnswerSemaphore.Done()
+	ttl := g.QuestionTimeout
 	timer := time.NewTimer(ttl)
 	start := time.Now()
+	hints := make(map[string]hint)
 	for {
 		select {
 		case wsMsg := <-player.readChan:
@@ -148,8 +253,17 @@ func (g *Game) waitForAnswers(player Player, question question, rightAnswer stri
 				g.StopGame <- true
 				return
 			}
+			if wsMsg.msg.Type == HINT {
+				sendErr := player.sendJSON(g.processHint(wsMsg.msg, question, validator, player, hints))
+				if sendErr != nil {
+					fmt.Println("Error sending to", player.Id)
+					g.StopGame <- true
+					return
+				}
+				continue
+			}
 			fractionOfTime := time.Now().Sub(start).Seconds()/ttl.Seconds()
-			reply, err := g.processAnswer(wsMsg.msg, question, rightAnswer, player, fractionOfTime)
+			reply, err := g.processAnswer(wsMsg.msg, question, validator, player, fractionOfTime, hintPenalty(hints))
 			if err != nil{
 				fmt.Println(err)
 			}
@@ -161,7 +275,12 @@ func (g *Game) waitForAnswers(player Player, question question, rightAnswer stri
 			}
 			return
 		case <-timer.C:
-			m := message{TIMEOUT, "It's too late buddy! 😭"}
+			g.recordAnswer(player, question, 0, 1)
+			m := message{TIMEOUT, status{
+				Result:      false,
+				Message:     "It's too late buddy! 😭",
+				Explanation: explain(question, player.Locale),
+			}}
 			sendErr := player.sendJSON(m)
 			if sendErr != nil {
 				fmt.Println("Error sending to", player.Id)
@@ -174,7 +293,33 @@ func (g *Game) waitForAnswers(player Player, question question, rightAnswer stri
 
 }
 
-func (g *Game) processAnswer(msg message, question question, rightAnswer string, player Player, fractionOfTime float64) (message, error){
+// processHint answers a player's request for a hint. Hints they already got
+// are given again at no extra cost.
+func (g *Game) processHint(msg message, question question, validator Validator, player Player, taken map[string]hint) message {
+	request := hintRequest{}
+	mapstructure.Decode(msg.Content, &request)
+	h, ok := taken[request.Kind]
+	if !ok {
+		h.Kind = request.Kind
+		var err error
+		if request.Id != question.Id {
+			err = errors.New("Hint ID doesn't match question ID")
+		} else {
+			h, err = giveHint(request.Kind, question, validator, player.Locale)
+		}
+		if err != nil {
+			h.Error = err.Error()
+		} else {
+			taken[request.Kind] = h
+		}
+	}
+	h.Penalty = hintPenalty(taken)
+	return message{HINT, h}
+}
+
+// processAnswer grades the answer. penalty is the share of the points lost to
+// hints.
+func (g *Game) processAnswer(msg message, question question, validator Validator, player Player, fractionOfTime float64, penalty float64) (message, error){
 	if msg.Type != ANSWER {
 		fmt.Print("You fucked up")
 	}
@@ -185,18 +330,84 @@ func (g *Game) processAnswer(msg message, question question, rightAnswer string,
 	}
 	m := message{}
 	m.Type = STATUS
-	if answer.Capital == rightAnswer {
-		score := int((1 - fractionOfTime) * 100)
-		g.scores[player.Id] += score
+	credit := validator.Grade(answer)
+	score := int(credit * (1 - penalty) * (1 - fractionOfTime) * 100)
+	g.scores[player.Id] += score
+	g.recordAnswer(player, question, credit * (1 - penalty), fractionOfTime)
+	solution := validator.Solution()
+	if v, ok := validator.(optionAnswerer); ok {
+		solution = question.option(v.AnswerId())
+	}
+	multiple, ordered := false, false
+	if v, ok := validator.(selectionsAnswerer); ok {
+		solution = strings.Join(question.labels(v.AnswerIds()), ", ")
+		multiple = true
+	}
+	if v, ok := validator.(orderAnswerer); ok {
+		solution = strings.Join(question.labels(v.Order()), " → ")
+		ordered = true
+	}
+	if v, ok := validator.(textValidator); ok {
+		// the capital the player was going for, or else the one in their
+		// language
+		if _, spelling := v.closest(answer); credit > 0 {
+			solution = spelling
+		} else if c, found := countriesByCode[question.subject]; found {
+			solution = c.text(CapitalField, player.Locale)
+		}
+	}
+	if v, ok := validator.(distanceValidator); ok {
+		distance := v.Distance(answer)
+		m.Content = status{
+			Result:   credit > 0,
+			Message:  fmt.Sprintf("📍 You were %.0f km away from %s. +%d pts", distance, v.solutionIn(player.Locale), score),
+			Distance: distance,
+		}
+	} else if credit == 1 {
 		m.Content = status{
 			Result:  true,
 			Message: fmt.Sprintf("🌎 You got it right! +%d pts", score),
 		}
+	} else if credit > 0 && ordered {
+		m.Content = status{
+			Result:  true,
+			Message: fmt.Sprintf("🤏 Almost! The right order was %s. +%d pts", solution, score),
+		}
+	} else if credit > 0 && multiple {
+		m.Content = status{
+			Result:  true,
+			Message: fmt.Sprintf("🤏 Partly right! The answers were %s. +%d pts", solution, score),
+		}
+	} else if credit > 0 {
+		m.Content = status{
+			Result:  true,
+			Message: fmt.Sprintf("🤏 Close enough! It's spelled %s. +%d pts", solution, score),
+		}
 	} else {
 		m.Content = status{
 			Result:  false,
-			Message: fmt.Sprintf("👎 Someone needs to buy an atlas. +0 pts. Right answer was %s ans you sent %s", rightAnswer, answer.Capital),
+			Message: fmt.Sprintf("👎 Someone needs to buy an atlas. +0 pts. Right answer was %s ans you sent %s", solution, answer.given(question)),
 		}
 	}
+	if r, ok := validator.(revealer); ok {
+		s := m.Content.(status)
+		s.Reveal = r.Reveal(player.Locale)
+		m.Content = s
+	}
+	if e := explain(question, player.Locale); e != nil {
+		s := m.Content.(status)
+		s.Explanation = e
+		m.Content = s
+	}
 	return m, nil
 }
+
+func (g *Game) recordAnswer(player Player, question question, credit float64, fractionOfTime float64) {
+	recorder, ok := g.Recorders[player.Id]
+	if !ok {
+		return
+	}
+	if c, found := countriesByCode[question.subject]; found {
+		recorder.record(c, credit, fractionOfTime)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+const EARTH_RADIUS_KM = 6371
+
+// DISTANCE_SCALE_KM is how far off a coordinate guess can be before it
+// loses about two thirds of its points.
+const DISTANCE_SCALE_KM = 1000
+
+// distanceValidator grades coordinate guesses by how far they land from a
+// target, discounting the radius of the target itself. The target is the
+// field of subject, its name or its capital.
+type distanceValidator struct {
+	lat     float64
+	lon     float64
+	radius  float64
+	subject country
+	field   string
+}
+
+func (v distanceValidator) Grade(a answer) float64 {
+	credit := math.Exp(-v.Distance(a) / DISTANCE_SCALE_KM)
+	if credit < 0.01 {
+		return 0
+	}
+	return credit
+}
+
+func (v distanceValidator) Solution() string {
+	return v.solutionIn(DEFAULT_LOCALE)
+}
+
+// solutionIn names the target in locale, with its coordinates.
+func (v distanceValidator) solutionIn(locale string) string {
+	return fmt.Sprintf("%s (%.2f, %.2f)", v.subject.text(v.field, locale), v.lat, v.lon)
+}
+
+// Distance is how many kilometers the guess landed from the target.
+func (v distanceValidator) Distance(a answer) float64 {
+	return math.Max(0, haversine(a.Lat, a.Lon, v.lat, v.lon)-v.radius)
+}
+
+// haversine is the great-circle distance in kilometers between two points
+// given in degrees.
+func haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	toRadians := math.Pi / 180
+	dLat := (lat2 - lat1) * toRadians
+	dLon := (lon2 - lon1) * toRadians
+	h := math.Pow(math.Sin(dLat/2), 2) + math.Cos(lat1*toRadians)*math.Cos(lat2*toRadians)*math.Pow(math.Sin(dLon/2), 2)
+	return 2 * EARTH_RADIUS_KM * math.Asin(math.Sqrt(h))
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHaversine(t *testing.T) {
+	tests := []struct {
+		name                   string
+		lat1, lon1, lat2, lon2 float64
+		km                     float64
+	}{
+		{"same point", 48.86, 2.35, 48.86, 2.35, 0},
+		{"a degree along the equator", 0, 0, 0, 1, 111.2},
+		{"pole to pole", 90, 0, -90, 0, 20015.1},
+		{"across the antimeridian", 0, 179.5, 0, -179.5, 111.2},
+		{"Paris to London", 48.86, 2.35, 51.51, -0.13, 343.8},
+	}
+	for _, test := range tests {
+		km := haversine(test.lat1, test.lon1, test.lat2, test.lon2)
+		if math.Abs(km-test.km) > 0.5 {
+			t.Errorf("%s: got %.1f km, want %.1f", test.name, km, test.km)
+		}
+		if back := haversine(test.lat2, test.lon2, test.lat1, test.lon1); math.Abs(back-km) > 1e-9 {
+			t.Errorf("%s: %.1f km there but %.1f km back", test.name, km, back)
+		}
+	}
+}
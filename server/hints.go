@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+)
+
+const (
+	FirstLetterHint = "firstLetter"
+	ContinentHint   = "continent"
+	EliminateHint   = "eliminate"
+)
+
+// hintPenalties are the share of a question's points each hint takes away.
+// They add up when a player asks for several.
+var hintPenalties = map[string]float64{
+	FirstLetterHint: 0.3,
+	ContinentHint:   0.2,
+	EliminateHint:   0.4,
+}
+
+// hintRequest is what players send to ask for a hint about a question.
+type hintRequest struct {
+	Id   string
+	Kind string
+}
+
+type hint struct {
+	Kind string `json:"kind"`
+	Text string `json:"text,omitempty"`
+	// Eliminated are the IDs of wrong options taken out of the question.
+	Eliminated []string `json:"eliminated,omitempty"`
+	// Penalty is the share of the question's points the player has lost to
+	// hints so far.
+	Penalty float64 `json:"penalty"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// capitalHintKinds ask for a capital, whose first letter can be told.
+var capitalHintKinds = map[string]bool{CapitalKind: true, FreeTextKind: true}
+
+// continentHintKinds hide the country they're about, whose continent can be
+// told.
+var continentHintKinds = map[string]bool{
+	ReverseCapitalKind: true,
+	CoordinatesKind:    true,
+	OutlineKind:        true,
+	FlagKind:           true,
+}
+
+// hintPenalty sums the penalties of the hints taken, up to all the points.
+func hintPenalty(taken map[string]hint) float64 {
+	penalty := 0.0
+	for kind := range taken {
+		penalty += hintPenalties[kind]
+	}
+	return math.Min(penalty, 1)
+}
+
+// giveHint works out the hint of the given kind for question, in the
+// player's locale.
+func giveHint(kind string, question question, validator Validator, locale string) (hint, error) {
+	h := hint{Kind: kind}
+	subject, found := countriesByCode[question.subject]
+	switch kind {
+	case FirstLetterHint:
+		if !capitalHintKinds[question.Kind] || !found {
+			return h, fmt.Errorf("this question doesn't ask for a capital")
+		}
+		capital := []rune(subject.text(CapitalField, locale))
+		h.Text = fmt.Sprintf("The capital starts with %s", strings.ToUpper(string(capital[:1])))
+	case ContinentHint:
+		if !continentHintKinds[question.Kind] || !found {
+			return h, fmt.Errorf("this question doesn't hide a country")
+		}
+		h.Text = fmt.Sprintf("Think of %s", subject.Continent)
+	case EliminateHint:
+		v, ok := validator.(optionAnswerer)
+		if !ok || len(question.OptionIds) < 3 {
+			return h, fmt.Errorf("there are no options to spare")
+		}
+		// at least two options are left
+		for _, i := range rand.Perm(len(question.OptionIds)) {
+			id := question.OptionIds[i]
+			if id != v.AnswerId() && len(h.Eliminated) < minInt(2, len(question.OptionIds)-2) {
+				h.Eliminated = append(h.Eliminated, id)
+			}
+		}
+		h.Text = fmt.Sprintf("It's not %s", strings.Join(question.labels(h.Eliminated), " nor "))
+	default:
+		return h, fmt.Errorf("unknown hint %q", kind)
+	}
+	return h, nil
+}
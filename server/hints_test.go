@@ -0,0 +1,145 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHintKinds(t *testing.T) {
+	countriesByCode["KEN"] = country{Name: "Kenya", Alpha3: "KEN", Capital: "Nairobi", Continent: "Africa"}
+	options := []string{"Nairobi", "Kampala", "Dodoma", "Kigali"}
+	ids := []string{"1", "2", "3", "4"}
+	tests := []struct {
+		questionKind string
+		hintKind     string
+		text         string
+	}{
+		{CapitalKind, FirstLetterHint, "The capital starts with N"},
+		{FreeTextKind, FirstLetterHint, "The capital starts with N"},
+		{ReverseCapitalKind, FirstLetterHint, ""},
+		{FlagKind, FirstLetterHint, ""},
+		{TrueFalseKind, FirstLetterHint, ""},
+		{BorderCountKind, FirstLetterHint, ""},
+		{ReverseCapitalKind, ContinentHint, "Think of Africa"},
+		{CoordinatesKind, ContinentHint, "Think of Africa"},
+		{OutlineKind, ContinentHint, "Think of Africa"},
+		{FlagKind, ContinentHint, "Think of Africa"},
+		{CapitalKind, ContinentHint, ""},
+		{BordersKind, ContinentHint, ""},
+		{CapitalKind, "nextQuestion", ""},
+	}
+	for _, test := range tests {
+		q := question{Kind: test.questionKind, Options: options, OptionIds: ids, subject: "KEN"}
+		h, err := giveHint(test.hintKind, q, optionValidator{"1", "Nairobi"}, DEFAULT_LOCALE)
+		if test.text == "" && err == nil {
+			t.Errorf("%s hint on %s question: got %q, want an error", test.hintKind, test.questionKind, h.Text)
+		}
+		if test.text != "" && (err != nil || h.Text != test.text) {
+			t.Errorf("%s hint on %s question: got %q, %v, want %q", test.hintKind, test.questionKind, h.Text, err, test.text)
+		}
+	}
+}
+
+func TestEliminateHint(t *testing.T) {
+	for options := 2; options <= MAX_OPTIONS; options++ {
+		q := question{Kind: CapitalKind}
+		for i := 0; i < options; i++ {
+			q.Options = append(q.Options, string(rune('A'+i)))
+		}
+		answerId := q.setOptions(q.Options, "A")
+		h, err := giveHint(EliminateHint, q, optionValidator{answerId, "A"}, DEFAULT_LOCALE)
+		if options < 3 {
+			if err == nil {
+				t.Errorf("%d options: took out %v", options, h.Eliminated)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%d options: %v", options, err)
+			continue
+		}
+		if want := minInt(2, options-2); len(h.Eliminated) != want {
+			t.Errorf("%d options: took out %d, want %d", options, len(h.Eliminated), want)
+		}
+		for _, id := range h.Eliminated {
+			if id == answerId {
+				t.Errorf("%d options: took out the answer", options)
+			}
+		}
+	}
+	q := question{Kind: SelectAllKind, Options: []string{"A", "B", "C", "D"}, OptionIds: []string{"1", "2", "3", "4"}}
+	if _, err := giveHint(EliminateHint, q, selectionValidator{answerIds: []string{"1", "2"}}, DEFAULT_LOCALE); err == nil {
+		t.Error("took out options of a question with several right ones")
+	}
+}
+
+func TestHintPenalty(t *testing.T) {
+	tests := []struct {
+		taken   []string
+		penalty float64
+	}{
+		{nil, 0},
+		{[]string{FirstLetterHint}, 0.3},
+		{[]string{ContinentHint, EliminateHint}, 0.6},
+		{[]string{FirstLetterHint, ContinentHint, EliminateHint}, 0.9},
+	}
+	for _, test := range tests {
+		taken := make(map[string]hint)
+		for _, kind := range test.taken {
+			taken[kind] = hint{Kind: kind}
+		}
+		if penalty := hintPenalty(taken); math.Abs(penalty-test.penalty) > 1e-9 {
+			t.Errorf("%v: got %v, want %v", test.taken, penalty, test.penalty)
+		}
+	}
+}
+
+func TestAskingAgainCostsNothing(t *testing.T) {
+	countriesByCode["KEN"] = country{Name: "Kenya", Alpha3: "KEN", Capital: "Nairobi", Continent: "Africa"}
+	q := question{Id: "q1", Kind: CapitalKind, Options: []string{"Nairobi", "Kampala", "Dodoma", "Kigali"}, subject: "KEN"}
+	answerId := q.setOptions(q.Options, "Nairobi")
+	validator := optionValidator{answerId, "Nairobi"}
+	g := &Game{}
+	taken := make(map[string]hint)
+	ask := func(id string, kind string) hint {
+		m := g.processHint(message{HINT, map[string]interface{}{"id": id, "kind": kind}}, q, validator, Player{Locale: DEFAULT_LOCALE}, taken)
+		return m.Content.(hint)
+	}
+	steps := []struct {
+		id, kind string
+		penalty  float64
+		failed   bool
+	}{
+		{"q1", FirstLetterHint, 0.3, false},
+		{"q1", FirstLetterHint, 0.3, false},
+		{"q1", ContinentHint, 0.3, true},
+		{"q0", EliminateHint, 0.3, true},
+		{"q1", EliminateHint, 0.7, false},
+		{"q1", EliminateHint, 0.7, false},
+	}
+	var eliminated []string
+	for i, step := range steps {
+		h := ask(step.id, step.kind)
+		if math.Abs(h.Penalty-step.penalty) > 1e-9 || (h.Error != "") != step.failed {
+			t.Errorf("step %d, %s: got penalty %v and error %q", i, step.kind, h.Penalty, h.Error)
+		}
+		if step.kind == EliminateHint && !step.failed {
+			if eliminated != nil && !equalStrings(eliminated, h.Eliminated) {
+				t.Errorf("step %d: took out %v, then %v", i, eliminated, h.Eliminated)
+			}
+			eliminated = h.Eliminated
+		}
+	}
+}
+
+func equalStrings(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,39 @@
+package main
+
+import "sync"
+
+// RECENT_HISTORY_SIZE is how many countries are remembered per player.
+const RECENT_HISTORY_SIZE = 50
+
+// playerHistory remembers the countries each player was recently asked
+// about, across games.
+type playerHistory struct {
+	mux    sync.Mutex
+	recent map[string][]string
+}
+
+func (h *playerHistory) record(playerID string, code string) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	if h.recent == nil {
+		h.recent = make(map[string][]string)
+	}
+	seen := append(h.recent[playerID], code)
+	if len(seen) > RECENT_HISTORY_SIZE {
+		seen = seen[len(seen)-RECENT_HISTORY_SIZE:]
+	}
+	h.recent[playerID] = seen
+}
+
+// recentFor returns the countries any of the players was recently asked about.
+func (h *playerHistory) recentFor(playerIDs []string) map[string]bool {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	recent := make(map[string]bool)
+	for _, playerID := range playerIDs {
+		for _, code := range h.recent[playerID] {
+			recent[code] = true
+		}
+	}
+	return recent
+}
@@ -3,11 +3,13 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 	"io/ioutil"
 	"math/rand"
 	"net/http"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
@@ -16,21 +18,110 @@ type Hub struct {
 	Connections    map[string]*websocket.Conn
 	Games          sync.Map
 	PlayerGameMap  sync.Map
+	Packs          sync.Map
+	History        playerHistory
+	Practice       *practiceStore
+	Daily          *dailyStore
 	GamesMux       sync.Mutex
 	ConnectionsMux sync.Mutex
 	Upgrader       websocket.Upgrader
 	Handler 	   http.HandlerFunc
 	CreateGame 	   http.HandlerFunc
+	UploadPack     http.HandlerFunc
+	PracticeGame   http.HandlerFunc
+	DailyGame      http.HandlerFunc
+	DailyLeaderboard http.HandlerFunc
 	UnregisterGame chan int
 }
 
 type CreateGameRequest struct {
 	Players []string `json:"players"`
-	Rounds	int      `json:rounds`
+	Rounds	int      `json:"rounds"`
+	Mode    string   `json:"mode"`
+	Modes   []string `json:"modes"`
+	Difficulty string `json:"difficulty"`
+	Tolerance  *int   `json:"tolerance"`
+	Pack       string `json:"pack"`
+	Seed       *int64 `json:"seed"`
+	// AvoidRecent skips countries the players were asked about in their
+	// previous games. Their histories differ between runs, so it can't be
+	// combined with a seed.
+	AvoidRecent bool `json:"avoidRecent"`
+	// Adaptive gives each player their own questions, leaning towards the
+	// countries and regions they get wrong or answer slowly. Their questions
+	// depend on their answers, so it can't be combined with a seed either.
+	Adaptive bool `json:"adaptive"`
+	CountryFilter
+	// Options is the number of options of each question, TimeLimit how many
+	// seconds players have to answer and Intermission how many seconds pass
+	// between questions. Unset ones keep their defaults.
+	Options      int  `json:"options"`
+	TimeLimit    int  `json:"timeLimit"`
+	Intermission *int `json:"intermission"`
+}
+
+// gameSettings are the validated options count, time limit and intermission
+// of a game.
+type gameSettings struct {
+	options      int
+	timeout      time.Duration
+	intermission time.Duration
+}
+
+func (request CreateGameRequest) settings() (gameSettings, error) {
+	settings := gameSettings{DEFAULT_OPTIONS, QUESTION_TIMEOUT, INTERMISSION}
+	if request.Options != 0 {
+		if request.Options < MIN_OPTIONS || request.Options > MAX_OPTIONS {
+			return settings, fmt.Errorf("questions have between %d and %d options", MIN_OPTIONS, MAX_OPTIONS)
+		}
+		settings.options = request.Options
+	}
+	if request.TimeLimit != 0 {
+		settings.timeout = time.Duration(request.TimeLimit) * time.Second
+		if settings.timeout < MIN_QUESTION_TIMEOUT || settings.timeout > MAX_QUESTION_TIMEOUT {
+			return settings, fmt.Errorf("the time limit is between %v and %v", MIN_QUESTION_TIMEOUT, MAX_QUESTION_TIMEOUT)
+		}
+	}
+	if request.Intermission != nil {
+		settings.intermission = time.Duration(*request.Intermission) * time.Second
+		if settings.intermission < 0 || settings.intermission > MAX_INTERMISSION {
+			return settings, fmt.Errorf("the intermission is between 0s and %v", MAX_INTERMISSION)
+		}
+	}
+	return settings, nil
+}
+
+// checkPack rejects the fields of request that choose countries to ask about,
+// which pack games don't.
+func (request CreateGameRequest) checkPack() error {
+	filter := request.CountryFilter
+	switch {
+	case request.Adaptive:
+		return fmt.Errorf("question packs can't adapt to the players")
+	case request.Mode != "" || len(request.Modes) > 0:
+		return fmt.Errorf("question packs can't be combined with a mode")
+	case request.Difficulty != "":
+		return fmt.Errorf("question packs can't be combined with a difficulty")
+	case request.Tolerance != nil:
+		return fmt.Errorf("question packs can't be combined with a tolerance")
+	case request.AvoidRecent:
+		return fmt.Errorf("question packs can't avoid recent countries")
+	case len(filter.Continents) > 0 || filter.SovereignOnly || filter.MinPopulation != 0 || filter.MaxPopulation != 0:
+		return fmt.Errorf("question packs can't be combined with country filters")
+	}
+	return nil
 }
 
 type CreateGameResponse struct {
 	GameID int `json:"gameID"`
+	// Difficulty is left out for pack games, which ask the pack's questions.
+	Difficulty string `json:"difficulty,omitempty"`
+	Seed int64 `json:"seed"`
+}
+
+type UploadPackResponse struct {
+	PackID    string `json:"packID"`
+	Questions int    `json:"questions"`
 }
 
 func (hub *Hub) InitHub() {
@@ -38,8 +129,23 @@ func (hub *Hub) InitHub() {
 		playerID := r.Header.Get("userID")
 		gameID, _ := strconv.Atoi(r.Header.Get("gameID"))
 		retrievedGameID, ok := hub.PlayerGameMap.Load(playerID)
+		foundGame, found := hub.Games.Load(gameID)
 
-		if ok && retrievedGameID == gameID {
+		if ok && retrievedGameID == gameID && found {
+			game := foundGame.(*Game)
+			game.joinMux.Lock()
+			defer game.joinMux.Unlock()
+			if game.abandoned {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			if game.Joining != nil {
+				if err := game.Joining(playerID); err != nil {
+					w.WriteHeader(http.StatusConflict)
+					w.Write([]byte(err.Error()))
+					return
+				}
+			}
 			wsConnection, err := hub.Upgrader.Upgrade(w, r, nil)
 			if err != nil {
 				panic(err)
@@ -51,10 +157,8 @@ func (hub *Hub) InitHub() {
 			hub.ConnectionsMux.Lock()
 			hub.Connections[playerID] = wsConnection
 			player := Player{}
-			player.New(playerID, hub.Connections[playerID])
+			player.New(playerID, hub.Connections[playerID], baseLocale(r.Header.Get("locale")))
 			hub.ConnectionsMux.Unlock()
-			foundGame, _ := hub.Games.Load(gameID)
-			game := foundGame.(*Game)
 			game.addPlayer(player)
 			game.JoinSemaphore.Done()
 		} else {
@@ -73,21 +177,276 @@ func (hub *Hub) InitHub() {
 		s1 := rand.NewSource(time.Now().UnixNano())
 		r1 := rand.New(s1)
 		gameID := r1.Intn(10000)
+		// kept below 2^53 so that it survives a trip through a JSON number
+		seed := r1.Int63n(1 << 53)
+		if gameRequest.Seed != nil {
+			seed = *gameRequest.Seed
+		}
+		if gameRequest.Seed != nil && gameRequest.AvoidRecent {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("avoidRecent can't be combined with a seed"))
+			return
+		}
+		if gameRequest.Seed != nil && gameRequest.Adaptive {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("adaptive games can't be combined with a seed"))
+			return
+		}
 
-		game = new(Game)
-		game.New(len(gameRequest.Players), gameRequest.Rounds, gameID, hub.UnregisterGame)
-		hub.Games.Store(gameID, game)
+		settings, err := gameRequest.settings()
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		// newPool builds the pool of the given players' questions
+		newPool := func(playerIDs []string) (*countryPool, error) {
+			countries, err := gameRequest.apply(capitals)
+			if err != nil {
+				return nil, err
+			}
+			pool, err := newCountryPool(countries, settings.options, gameRequest.Difficulty, seed)
+			if err != nil {
+				return nil, err
+			}
+			if gameRequest.AvoidRecent {
+				pool.recent = hub.History.recentFor(playerIDs)
+			}
+			pool.seen = func(c country) {
+				for _, playerID := range playerIDs {
+					hub.History.record(playerID, c.Alpha3)
+				}
+			}
+			return pool, nil
+		}
+		var questions QuestionSource
+		var difficulty string
+		playerQuestions := make(map[string]QuestionSource)
+		recorders := make(map[string]answerRecorder)
+		if gameRequest.Pack != "" {
+			if err := gameRequest.checkPack(); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(err.Error()))
+				return
+			}
+			pack, ok := hub.Packs.Load(gameRequest.Pack)
+			if !ok {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(fmt.Sprintf("unknown question pack %q", gameRequest.Pack)))
+				return
+			}
+			if problems := validatePack(pack.(*questionPack), settings.options); len(problems) > 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				for _, problem := range problems {
+					fmt.Fprintln(w, problem)
+				}
+				return
+			}
+			questions = newPackSource(pack.(*questionPack), settings.options, seed)
+		} else {
+			pool, err := newPool(gameRequest.Players)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(err.Error()))
+				return
+			}
+			difficulty = pool.difficulty
+			if gameRequest.Adaptive {
+				for _, playerID := range gameRequest.Players {
+					playerPool, _ := newPool([]string{playerID})
+					stats := newAnswerStats()
+					recorders[playerID] = stats
+					playerPool.weight = stats.weight
+					playerPool.revisit = stats.revisit
+					playerQuestions[playerID], err = newQuestionSource(gameRequest, playerPool)
+					if err != nil {
+						w.WriteHeader(http.StatusBadRequest)
+						w.Write([]byte(err.Error()))
+						return
+					}
+				}
+			} else {
+				questions, err = newQuestionSource(gameRequest, pool)
+				if err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					w.Write([]byte(err.Error()))
+					return
+				}
+			}
+		}
 
-		for _, playerID := range gameRequest.Players{
-			hub.PlayerGameMap.Store(playerID, gameID)
+		game = new(Game)
+		game.New(len(gameRequest.Players), gameRequest.Rounds, gameID, hub.UnregisterGame, questions)
+		game.QuestionTimeout = settings.timeout
+		game.Intermission = settings.intermission
+		if gameRequest.Adaptive {
+			game.PlayerQuestions = playerQuestions
+			game.Recorders = recorders
 		}
+		hub.registerGame(game, gameRequest.Players)
 		fmt.Printf("Created game %d with players:%v\n", gameID, gameRequest.Players)
-		respBody := CreateGameResponse{GameID: gameID}
+		respBody := CreateGameResponse{GameID: gameID, Difficulty: difficulty, Seed: seed}
 		respData, err := json.Marshal(respBody)
 		if err != nil {
 			panic(err)
 		}
-		go hub.startGame(game)
+		w.WriteHeader(http.StatusCreated)
+		w.Write(respData)
+	}
+	hub.PracticeGame = func(w http.ResponseWriter, r *http.Request){
+		var practiceRequest PracticeRequest
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			panic(err)
+		}
+		json.Unmarshal(body, &practiceRequest)
+		if practiceRequest.Player == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("practice needs a player"))
+			return
+		}
+		rounds := practiceRequest.Rounds
+		if rounds <= 0 {
+			rounds = PRACTICE_ROUNDS
+		}
+
+		r1 := rand.New(rand.NewSource(time.Now().UnixNano()))
+		gameID := r1.Intn(10000)
+		pool, err := newCountryPool(capitals, DEFAULT_OPTIONS, practiceRequest.Difficulty, r1.Int63n(1 << 53))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		deck := hub.Practice.deck(practiceRequest.Player)
+		pool.weight = deck.weight
+		pool.seen = func(c country) {
+			hub.History.record(practiceRequest.Player, c.Alpha3)
+		}
+		questions, err := newQuestionSource(CreateGameRequest{Mode: practiceRequest.Mode}, pool)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		game := new(Game)
+		game.New(1, rounds, gameID, hub.UnregisterGame, questions)
+		game.Recorders = map[string]answerRecorder{practiceRequest.Player: deck}
+		game.JoinTimeout = SOLO_JOIN_TIMEOUT
+		hub.registerGame(game, []string{practiceRequest.Player})
+		fmt.Printf("Created practice game %d for %s\n", gameID, practiceRequest.Player)
+		respData, err := json.Marshal(PracticeResponse{GameID: gameID, Due: deck.due()})
+		if err != nil {
+			panic(err)
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write(respData)
+	}
+	// DailyGame starts the player's go at today's challenge. Each player
+	// plays alone, whenever they like, on the questions everyone gets that day.
+	hub.DailyGame = func(w http.ResponseWriter, r *http.Request){
+		var dailyRequest DailyRequest
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			panic(err)
+		}
+		json.Unmarshal(body, &dailyRequest)
+		if dailyRequest.Player == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("the daily challenge needs a player"))
+			return
+		}
+		date := today()
+		if hub.Daily.played(date, dailyRequest.Player) {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(errPlayedToday.Error()))
+			return
+		}
+
+		gameID := rand.New(rand.NewSource(time.Now().UnixNano())).Intn(10000)
+		pool, err := newCountryPool(capitals, DEFAULT_OPTIONS, DAILY_DIFFICULTY, dailySeed(date))
+		if err != nil {
+			panic(err)
+		}
+		pool.seen = func(c country) {
+			hub.History.record(dailyRequest.Player, c.Alpha3)
+		}
+		questions, err := newQuestionSource(CreateGameRequest{Mode: MixedMode}, pool)
+		if err != nil {
+			panic(err)
+		}
+
+		game := new(Game)
+		game.New(1, DAILY_ROUNDS, gameID, hub.UnregisterGame, questions)
+		game.Finished = func(scores map[string]int) {
+			hub.Daily.finish(date, dailyRequest.Player, scores[dailyRequest.Player])
+		}
+		// the player is entered once they join, so that a game they never
+		// join doesn't use up their go
+		game.Joining = func(playerID string) error {
+			if !hub.Daily.start(date, playerID) {
+				return errPlayedToday
+			}
+			return nil
+		}
+		game.JoinTimeout = SOLO_JOIN_TIMEOUT
+		hub.registerGame(game, []string{dailyRequest.Player})
+		fmt.Printf("Created daily game %d for %s\n", gameID, dailyRequest.Player)
+		respData, err := json.Marshal(DailyResponse{GameID: gameID, Date: date})
+		if err != nil {
+			panic(err)
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write(respData)
+	}
+	hub.DailyLeaderboard = func(w http.ResponseWriter, r *http.Request){
+		date := r.URL.Query().Get("date")
+		if date == "" {
+			date = today()
+		}
+		if _, err := time.Parse(DATE_LAYOUT, date); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(fmt.Sprintf("dates look like %s", DATE_LAYOUT)))
+			return
+		}
+		respData, err := json.Marshal(hub.Daily.leaderboard(date))
+		if err != nil {
+			panic(err)
+		}
+		w.Write(respData)
+	}
+	hub.UploadPack = func(w http.ResponseWriter, r *http.Request){
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		format := "json"
+		if strings.Contains(r.Header.Get("Content-Type"), "csv") {
+			format = "csv"
+		}
+		pack, err := parsePack(http.MaxBytesReader(w, r.Body, MAX_PACK_SIZE), format, r.URL.Query().Get("name"))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		// games check the pack again against the number of options they use
+		if problems := validatePack(pack, MIN_OPTIONS); len(problems) > 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			for _, problem := range problems {
+				fmt.Fprintln(w, problem)
+			}
+			return
+		}
+		pack.Id = uuid.New().String()
+		hub.Packs.Store(pack.Id, pack)
+		fmt.Printf("Stored pack %s with %d questions\n", pack.Id, len(pack.Questions))
+		respData, err := json.Marshal(UploadPackResponse{PackID: pack.Id, Questions: len(pack.Questions)})
+		if err != nil {
+			panic(err)
+		}
 		w.WriteHeader(http.StatusCreated)
 		w.Write(respData)
 	}
@@ -116,15 +475,45 @@ func (hub *Hub) start(){
 	}
 }
 
-func (hub *Hub) startGame(game *Game) {
+// registerGame lets the players join game, which starts once they all have.
+func (hub *Hub) registerGame(game *Game, playerIDs []string) {
+	hub.Games.Store(game.Id, game)
+	for _, playerID := range playerIDs {
+		hub.PlayerGameMap.Store(playerID, game.Id)
+	}
+	go hub.startGame(game, playerIDs)
+}
+
+func (hub *Hub) startGame(game *Game, playerIDs []string) {
 	initMessage := message{ACKNOWLEDGED, "Let the games begin! 😈"}
-	game.JoinSemaphore.Wait()
+	if !game.waitForPlayers() {
+		hub.abandonGame(game, playerIDs)
+		return
+	}
 	fmt.Println("Starting Game")
 	game.startReadingFromAllPlayers()
 	game.sendMessageToAllPlayers(initMessage)
 	go game.play()
 }
 
+// abandonGame clears game, called off before all its players joined.
+func (hub *Hub) abandonGame(game *Game, playerIDs []string) {
+	for _, player := range game.Players[:game.OnlinePlayers] {
+		player.dropConnection()
+		hub.ConnectionsMux.Lock()
+		delete(hub.Connections, player.Id)
+		hub.ConnectionsMux.Unlock()
+	}
+	for _, playerID := range playerIDs {
+		// the player may have moved on to another game since
+		if gameID, _ := hub.PlayerGameMap.Load(playerID); gameID == game.Id {
+			hub.PlayerGameMap.Delete(playerID)
+		}
+	}
+	hub.Games.Delete(game.Id)
+	fmt.Printf("Called off game %d, not everyone joined\n", game.Id)
+}
+
 func(hub *Hub) finishGame(game *Game){
 	for _, player := range game.Players{
 		player.dropConnection()
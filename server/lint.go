@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// lintReport collects the problems found in an asset. Errors make the asset
+// unusable, warnings are suspicious but may be legitimate.
+type lintReport struct {
+	errors   []string
+	warnings []string
+}
+
+// lint checks the country dataset and any question packs given as
+// arguments, printing every problem found. It returns the exit status of the
+// lint command: 1 when there are errors, or warnings with -strict.
+func lint(args []string) int {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	countriesFile := flags.String("countries", CapitalsFile, "country dataset to check")
+	strict := flags.Bool("strict", false, "fail on warnings too")
+	options := flags.Int("options", DEFAULT_OPTIONS, "number of options the packs are played with")
+	flags.Usage = func() {
+		fmt.Println("usage: server lint [-countries file] [-strict] [-options n] [pack files...]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	reports := map[string]lintReport{*countriesFile: lintCountriesFile(*countriesFile)}
+	files := []string{*countriesFile}
+	for _, packFile := range flags.Args() {
+		reports[packFile] = lintPackFile(packFile, *options)
+		files = append(files, packFile)
+	}
+
+	status := 0
+	for _, file := range files {
+		report := reports[file]
+		for _, e := range report.errors {
+			fmt.Printf("%s: error: %s\n", file, e)
+		}
+		for _, w := range report.warnings {
+			fmt.Printf("%s: warning: %s\n", file, w)
+		}
+		if len(report.errors) > 0 || (*strict && len(report.warnings) > 0) {
+			status = 1
+		}
+	}
+	if status == 0 {
+		fmt.Println("Assets look good 🌎")
+	}
+	return status
+}
+
+// sharedCapitals are different cities with the same name, each the capital of
+// its own country. Any other capital listed twice is an error.
+var sharedCapitals = map[string]bool{
+	// Jamaica's and Norfolk Island's
+	"Kingston": true,
+}
+
+func lintCountriesFile(p string) lintReport {
+	var report lintReport
+	data, err := ioutil.ReadFile(p)
+	if err != nil {
+		report.errors = append(report.errors, err.Error())
+		return report
+	}
+	if !utf8.Valid(data) {
+		report.errors = append(report.errors, "file is not valid UTF-8")
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var countries []country
+	if err := decoder.Decode(&countries); err != nil {
+		report.errors = append(report.errors, fmt.Sprintf("schema: %v", err))
+		return report
+	}
+
+	_, problems := validateCountries(countries)
+	for _, problem := range problems {
+		report.errors = append(report.errors, problem.Error())
+	}
+	capitalOwners := make(map[string][]string)
+	for i, c := range countries {
+		texts := append(append([]string{c.Name}, c.capitals()...), c.CapitalAltSpellings...)
+		for _, t := range c.Translations {
+			texts = append(append(texts, t.Name, t.Capital), t.OtherCapitals...)
+		}
+		for _, text := range texts {
+			// the decoder swaps invalid UTF-8 for the replacement character
+			if strings.ContainsRune(text, utf8.RuneError) {
+				report.errors = append(report.errors, countryError{i, c.Name, fmt.Sprintf("%q is not valid UTF-8", text)}.Error())
+			}
+		}
+		if c.Capital != "" {
+			capitalOwners[c.Capital] = append(capitalOwners[c.Capital], c.Name)
+		}
+	}
+	for _, c := range countries {
+		owners := capitalOwners[c.Capital]
+		if len(owners) > 1 && owners[0] == c.Name && !sharedCapitals[c.Capital] {
+			report.errors = append(report.errors, fmt.Sprintf("%s is the capital of %s", c.Capital, strings.Join(owners, " and ")))
+		}
+	}
+	return report
+}
+
+// lintPackFile checks the pack in p can be played with the given number of
+// options.
+func lintPackFile(p string, options int) lintReport {
+	var report lintReport
+	data, err := ioutil.ReadFile(p)
+	if err != nil {
+		report.errors = append(report.errors, err.Error())
+		return report
+	}
+	if !utf8.Valid(data) {
+		report.errors = append(report.errors, "file is not valid UTF-8")
+	}
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(p)), ".")
+	pack, err := parsePack(bytes.NewReader(data), format, filepath.Base(p))
+	if err != nil {
+		report.errors = append(report.errors, err.Error())
+		return report
+	}
+	for _, problem := range validatePack(pack, options) {
+		report.errors = append(report.errors, problem.Error())
+	}
+	return report
+}
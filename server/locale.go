@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strings"
+)
+
+// DEFAULT_LOCALE is the language of the dataset's own names, used for players
+// who don't pick one or pick one without translations.
+const DEFAULT_LOCALE = "en"
+
+const (
+	NameField    = "name"
+	CapitalField = "capital"
+)
+
+// countriesByCode indexes the loaded countries by their alpha3 code.
+var countriesByCode = map[string]country{}
+
+// baseLocale reduces a locale such as "es-AR" to the language it's written in.
+func baseLocale(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(locale, "-_"); i >= 0 {
+		locale = locale[:i]
+	}
+	if locale == "" {
+		return DEFAULT_LOCALE
+	}
+	return locale
+}
+
+// text returns the country's name or capital in locale, falling back to
+// English when there's no translation.
+func (c country) text(field string, locale string) string {
+	t := c.Translations[locale]
+	switch field {
+	case CapitalField:
+		if t.Capital != "" {
+			return t.Capital
+		}
+		return c.Capital
+	default:
+		if t.Name != "" {
+			return t.Name
+		}
+		return c.Name
+	}
+}
+
+// localize returns a copy of q with the names of the countries and capitals
+// it mentions written in locale. Option IDs are left untouched so that the
+// answers of every player are graded the same way.
+func (q question) localize(locale string) question {
+	if locale == DEFAULT_LOCALE {
+		return q
+	}
+	if subject, ok := countriesByCode[q.subject]; ok {
+		if q.Country != "" {
+			q.Country = subject.text(NameField, locale)
+		}
+		if q.Capital != "" && q.capitalSubject == "" {
+			q.Capital = subject.text(CapitalField, locale)
+		}
+	}
+	if owner, ok := countriesByCode[q.capitalSubject]; ok {
+		q.Capital = owner.text(CapitalField, locale)
+	}
+	if q.optionCodes != nil {
+		options := make([]string, len(q.Options))
+		for i, code := range q.optionCodes {
+			options[i] = countriesByCode[code].text(q.optionField, locale)
+		}
+		q.Options = options
+	}
+	return q
+}
@@ -4,13 +4,9 @@ import (
 	"flag"
 	"fmt"
 	"net/http"
+	"os"
 	"path/filepath"
-
-	"encoding/json"
-	"github.com/google/uuid"
-	"io/ioutil"
-	"math/rand"
-	"time"
+	"strings"
 )
 
 var ACKNOWLEDGED = "acknowledged"
@@ -20,11 +16,14 @@ var ANSWER = "answer"
 var STATUS = "status"
 var ScoreUpdate = "scoreUpdate"
 var GAMEOVER = "gameOver"
+var HINT = "hint"
 
 var CapitalsFile = "server/assets/countries.json"
 var capitals []country
 
 var port = *flag.String("ip", "3434", "help message for flagname")
+var practiceFile = flag.String("practice", "server/data/practice.json", "file keeping the progress of solo practice")
+var dailyFile = flag.String("daily", "server/data/daily.json", "file keeping the scores of the daily challenges")
 
 type websocketMessage struct {
 	msg message
@@ -38,74 +37,129 @@ type message struct {
 
 type answer struct {
 	Id string
+	Kind string
+	// OptionId is the option picked, Selections those picked in questions
+	// with several right ones and Capital the text typed.
+	OptionId string
+	Selections []string
+	// Order is the option IDs in the order the player put them.
+	Order []string
 	Capital string
+	Lat float64
+	Lon float64
 }
 
 type question struct {
 	Id 		string `json:"id"`
-	Country string `json:"country"`
+	Kind    string `json:"kind"`
+	Country string `json:"country,omitempty"`
+	Capital string `json:"capital,omitempty"`
+	Prompt  string `json:"prompt,omitempty"`
+	Metric  string `json:"metric,omitempty"`
+	Region  string `json:"region,omitempty"`
+	Outline []string `json:"outline,omitempty"`
+	Flag    string `json:"flag,omitempty"`
+	Stripes *flagStripes `json:"stripes,omitempty"`
 	Options []string `json:"options"`
+	// OptionIds identify the options in answers, whatever language they were shown in.
+	OptionIds []string `json:"optionIds,omitempty"`
+	// alpha3 codes of the country asked about, of the one whose capital is
+	// shown when it's another and of the countries the options name, used to
+	// translate them
+	subject        string
+	capitalSubject string
+	optionCodes    []string
+	optionField    string
 }
 
 type status struct {
 	Result bool `json:"result"`
 	Message string `json:"message"`
-}
-
-type country struct {
-	Name    string
-	Capital string
+	Distance float64 `json:"distance,omitempty"`
+	Reveal string `json:"reveal,omitempty"`
+	Explanation *explanation `json:"explanation,omitempty"`
 }
 
 type gameOver struct{
 	Leaderboard map[string]int `json:"leaderboard"`
 }
 
-func fetchCapitals(p string) {
-	filePath,_ := filepath.Abs(p)
-	data, err := ioutil.ReadFile(filePath)
-	if err != nil {
-		panic(err)
+// option returns the label of the option with the given ID.
+func (q question) option(id string) string {
+	for i, optionId := range q.OptionIds {
+		if optionId == id {
+			return q.Options[i]
+		}
 	}
-	capitals = make([]country, 0)
-	json.Unmarshal(data, &capitals)
+	return id
 }
 
-func generateQuestion(n int) (question, string) {
-	s1 := rand.NewSource(time.Now().UnixNano())
-	r1 := rand.New(s1)
-	q := question{}
-	capitalsSet := make([]country, n)
-	options := make([]string, n)
-	selected := make(map[int]bool)
-	for i := 0; i < n; i++ {
-		index := r1.Intn(len(capitals))
-		for selected[index] {
-			index = r1.Intn(len(capitals))
-			fmt.Println(index)
-		}
-		selected[index] = true
-		capitalsSet[i] = capitals[index]
-		options[i] = capitals[index].Capital
+// labels returns the labels of the options with the given IDs.
+func (q question) labels(ids []string) []string {
+	labels := make([]string, len(ids))
+	for i, id := range ids {
+		labels[i] = q.option(id)
 	}
+	return labels
+}
 
-	answer := capitalsSet[r1.Intn(len(capitalsSet))]
-	q.Id = uuid.New().String()
-	q.Country = answer.Name
-	q.Options = options
-	return q, answer.Capital
+// given is what the player answered to q, as they saw it.
+func (a answer) given(q question) string {
+	if a.OptionId != "" {
+		return q.option(a.OptionId)
+	}
+	if a.Selections != nil {
+		return strings.Join(q.labels(a.Selections), ", ")
+	}
+	if a.Order != nil {
+		return strings.Join(q.labels(a.Order), " → ")
+	}
+	return a.Capital
+}
 
+func fetchCapitals(p string) {
+	filePath,_ := filepath.Abs(p)
+	countries, problems, err := loadCountries(filePath)
+	if err != nil {
+		panic(err)
+	}
+	for _, problem := range problems {
+		fmt.Println("Skipping", problem)
+	}
+	capitals = countries
+	for _, c := range countries {
+		countriesByCode[c.Alpha3] = c
+	}
 }
 
 func main() {
 	flag.Parse()
+	if flag.Arg(0) == "lint" {
+		os.Exit(lint(flag.Args()[1:]))
+	}
 	fmt.Println("Starting server... 🚀")
 	var hub = Hub{}
 	hub.InitHub()
 	fetchCapitals(CapitalsFile)
+	fetchOutlines()
+	fetchFlags()
+	practice, err := loadPracticeStore(*practiceFile)
+	if err != nil {
+		panic(err)
+	}
+	hub.Practice = practice
+	daily, err := loadDailyStore(*dailyFile)
+	if err != nil {
+		panic(err)
+	}
+	hub.Daily = daily
 	http.HandleFunc("/ws", hub.Handler)
 	http.HandleFunc("/game", hub.CreateGame)
-	err := http.ListenAndServe(":"+port, nil)
+	http.HandleFunc("/packs", hub.UploadPack)
+	http.HandleFunc("/practice", hub.PracticeGame)
+	http.HandleFunc("/daily", hub.DailyGame)
+	http.HandleFunc("/daily/leaderboard", hub.DailyLeaderboard)
+	err = http.ListenAndServe(":"+port, nil)
 	if err != nil {
 		panic(err)
 	}
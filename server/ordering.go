@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const OrderingKind = "ordering"
+
+// Countries in an ordering question.
+const (
+	MIN_ORDERED = 4
+	MAX_ORDERED = 5
+)
+
+// orderingMetrics are what countries can be put in order of. Sizes go from
+// the largest to the smallest, longitude from west to east and latitude from
+// north to south.
+var orderingMetrics = []string{"population", "area", "density", "longitude", "latitude"}
+
+// orderingSource shows a few countries and asks to put them in order.
+type orderingSource struct {
+	pool *countryPool
+}
+
+// orderingValidator grades orderings by their Kendall tau rank correlation
+// with the right one, so that swapping two neighbors costs less than
+// reversing the whole list. Orderings no better than chance earn nothing.
+type orderingValidator struct {
+	order  []string
+	sorted []country
+	metric string
+}
+
+// orderAnswerer is implemented by validators of questions answered by
+// putting the options in order, so the right one can be shown as the player
+// saw it.
+type orderAnswerer interface {
+	Order() []string
+}
+
+// orderable tells whether c can be ranked on metric.
+func orderable(c country, metric string) bool {
+	return metric == "longitude" || metric == "latitude" || measure(c, metric) > 0
+}
+
+// askable tells whether every metric ranks at least two countries of the pool
+// apart, since any of them may be asked.
+func (s *orderingSource) askable() bool {
+	for _, metric := range orderingMetrics {
+		keys := make(map[float64]bool)
+		for _, c := range s.pool.countries {
+			if orderable(c, metric) {
+				keys[orderKey(c, metric)] = true
+			}
+		}
+		if len(keys) < 2 {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *orderingSource) Next() (question, Validator) {
+	metric := orderingMetrics[s.pool.rng.Intn(len(orderingMetrics))]
+	valid := func(c country) bool {
+		return orderable(c, metric)
+	}
+	first, ok := s.pool.pickWhere(valid)
+	if !ok {
+		// ruled out by askable, kept as a guard
+		return (&capitalSource{s.pool}).Next()
+	}
+	count := MIN_ORDERED + s.pool.rng.Intn(MAX_ORDERED-MIN_ORDERED+1)
+	picked := []country{first}
+	used := map[float64]bool{orderKey(first, metric): true}
+	candidates := append([]country{}, s.pool.countries...)
+	s.pool.rng.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+	for _, c := range candidates {
+		if len(picked) == count {
+			break
+		}
+		// ties would have more than one right order
+		if !valid(c) || used[orderKey(c, metric)] {
+			continue
+		}
+		used[orderKey(c, metric)] = true
+		picked = append(picked, c)
+	}
+	if len(picked) < 2 {
+		// ruled out by askable, kept as a guard
+		return (&capitalSource{s.pool}).Next()
+	}
+	s.pool.rng.Shuffle(len(picked), func(i, j int) { picked[i], picked[j] = picked[j], picked[i] })
+
+	q := question{
+		Id:      uuid.New().String(),
+		Kind:    OrderingKind,
+		Metric:  metric,
+		subject: first.Alpha3,
+	}
+	q.setCountryOptions(picked, NameField, first)
+	ids := make(map[string]string)
+	for i, c := range picked {
+		ids[c.Alpha3] = q.OptionIds[i]
+	}
+	sorted := append([]country{}, picked...)
+	sort.Slice(sorted, func(i, j int) bool { return orderKey(sorted[i], metric) < orderKey(sorted[j], metric) })
+	order := make([]string, len(sorted))
+	for i, c := range sorted {
+		order[i] = ids[c.Alpha3]
+	}
+	return q, orderingValidator{order, sorted, metric}
+}
+
+// orderKey is the value countries are sorted by, smallest first.
+func orderKey(c country, metric string) float64 {
+	switch metric {
+	case "longitude":
+		return c.Lon
+	case "latitude":
+		return -c.Lat
+	default:
+		return -measure(c, metric)
+	}
+}
+
+func formatOrderKey(c country, metric string) string {
+	switch metric {
+	case "longitude":
+		return formatDegrees(c.Lon, "E", "W")
+	case "latitude":
+		return formatDegrees(c.Lat, "N", "S")
+	default:
+		return formatMeasure(c, metric)
+	}
+}
+
+func formatDegrees(degrees float64, positive string, negative string) string {
+	if degrees < 0 {
+		return fmt.Sprintf("%.1f°%s", -degrees, negative)
+	}
+	return fmt.Sprintf("%.1f°%s", degrees, positive)
+}
+
+// Grade gives no credit to answers that aren't an ordering of every option.
+func (v orderingValidator) Grade(a answer) float64 {
+	if len(a.Order) != len(v.order) {
+		return 0
+	}
+	rank := make(map[string]int)
+	for i, id := range v.order {
+		rank[id] = i
+	}
+	given := make([]int, len(a.Order))
+	seen := make(map[string]bool)
+	for i, id := range a.Order {
+		r, ok := rank[id]
+		if !ok || seen[id] {
+			return 0
+		}
+		seen[id] = true
+		given[i] = r
+	}
+	pairs, discordant := 0, 0
+	for i := range given {
+		for j := i + 1; j < len(given); j++ {
+			pairs++
+			if given[i] > given[j] {
+				discordant++
+			}
+		}
+	}
+	tau := 1 - 2*float64(discordant)/float64(pairs)
+	if tau < 0 {
+		return 0
+	}
+	return tau
+}
+
+func (v orderingValidator) Solution() string {
+	names := make([]string, len(v.sorted))
+	for i, c := range v.sorted {
+		names[i] = c.Name
+	}
+	return strings.Join(names, " → ")
+}
+
+func (v orderingValidator) Order() []string {
+	return v.order
+}
+
+func (v orderingValidator) Reveal(locale string) string {
+	figures := make([]string, len(v.sorted))
+	for i, c := range v.sorted {
+		figures[i] = fmt.Sprintf("%s: %s", c.text(NameField, locale), formatOrderKey(c, v.metric))
+	}
+	return strings.Join(figures, ", ")
+}
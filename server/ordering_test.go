@@ -0,0 +1,30 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestOrderingValidatorGrade(t *testing.T) {
+	v := orderingValidator{order: []string{"a", "b", "c", "d"}}
+	tests := []struct {
+		name   string
+		order  []string
+		credit float64
+	}{
+		{"right", []string{"a", "b", "c", "d"}, 1},
+		{"one neighbor swap", []string{"b", "a", "c", "d"}, 2.0 / 3},
+		{"two swaps", []string{"b", "a", "d", "c"}, 1.0 / 3},
+		{"ends swapped", []string{"d", "b", "c", "a"}, 0},
+		{"reversed", []string{"d", "c", "b", "a"}, 0},
+		{"too short", []string{"a", "b", "c"}, 0},
+		{"repeated option", []string{"a", "a", "c", "d"}, 0},
+		{"unknown option", []string{"a", "b", "c", "x"}, 0},
+		{"nothing", nil, 0},
+	}
+	for _, test := range tests {
+		if credit := v.Grade(answer{Order: test.order}); math.Abs(credit-test.credit) > 1e-9 {
+			t.Errorf("%s: got %v, want %v", test.name, credit, test.credit)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const OutlineKind = "outline"
+
+// Size of the drawn outlines, in terminal columns and rows. Each braille
+// character holds 2x4 dots.
+const (
+	OUTLINE_COLUMNS = 40
+	OUTLINE_ROWS    = 16
+)
+
+// outlinesData maps alpha3 codes to simplified borders, as rings of
+// [lon, lat] points. Rings inside another, like Lesotho in South Africa,
+// are holes. Only countries with a recognizable shape have one.
+//
+//go:embed assets/outlines.json
+var outlinesData []byte
+
+var outlines map[string][][][2]float64
+
+// outlineSource shows the shape of a country and asks which one it is.
+type outlineSource struct {
+	pool *countryPool
+}
+
+func fetchOutlines() {
+	parsed := make(map[string][][][2]float64)
+	if err := json.Unmarshal(outlinesData, &parsed); err != nil {
+		panic(fmt.Errorf("parsing outlines: %v", err))
+	}
+	outlines = parsed
+}
+
+func (s *outlineSource) askable() bool {
+	return s.pool.has(hasOutline)
+}
+
+func hasOutline(c country) bool {
+	return outlines[c.Alpha3] != nil
+}
+
+func (s *outlineSource) Next() (question, Validator) {
+	answer, ok := s.pool.pickWhere(hasOutline)
+	if !ok {
+		// ruled out by askable, kept as a guard
+		return (&capitalSource{s.pool}).Next()
+	}
+	picked := s.pool.withDistractors(answer, answer, nameOf, nil)
+	q := question{
+		Id:      uuid.New().String(),
+		Kind:    OutlineKind,
+		Outline: drawOutline(outlines[answer.Alpha3], OUTLINE_COLUMNS, OUTLINE_ROWS),
+		subject: answer.Alpha3,
+	}
+	return q, optionValidator{q.setCountryOptions(picked, NameField, answer), answer.Name}
+}
+
+// drawOutline fills the rings in braille characters, scaled to fit in
+// columns by rows. Longitudes are shrunk by the cosine of the latitude so
+// that countries far from the equator aren't stretched.
+func drawOutline(rings [][][2]float64, columns int, rows int) []string {
+	minLon, maxLon, minLat, maxLat := math.Inf(1), math.Inf(-1), math.Inf(1), math.Inf(-1)
+	for _, ring := range rings {
+		for _, p := range ring {
+			minLon, maxLon = math.Min(minLon, p[0]), math.Max(maxLon, p[0])
+			minLat, maxLat = math.Min(minLat, p[1]), math.Max(maxLat, p[1])
+		}
+	}
+	shrink := math.Cos((minLat + maxLat) / 2 * math.Pi / 180)
+	width, height := (maxLon-minLon)*shrink, maxLat-minLat
+	scale := math.Min(float64(columns*2)/width, float64(rows*4)/height)
+	dotsWide, dotsHigh := int(math.Ceil(width*scale)), int(math.Ceil(height*scale))
+
+	lines := make([]string, 0, rows)
+	for row := 0; row*4 < dotsHigh; row++ {
+		var line strings.Builder
+		for column := 0; column*2 < dotsWide; column++ {
+			cell := 0
+			for bit, dot := range brailleDots {
+				x, y := column*2+dot[0], row*4+dot[1]
+				lon := minLon + (float64(x)+0.5)/scale/shrink
+				lat := maxLat - (float64(y)+0.5)/scale
+				if inside(rings, lon, lat) {
+					cell |= 1 << uint(bit)
+				}
+			}
+			line.WriteRune(rune(0x2800 + cell))
+		}
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+// brailleDots lists the position of the dot each bit of a braille character
+// stands for.
+var brailleDots = [8][2]int{{0, 0}, {0, 1}, {0, 2}, {1, 0}, {1, 1}, {1, 2}, {0, 3}, {1, 3}}
+
+// inside tells whether the point falls in the rings by the even-odd rule.
+func inside(rings [][][2]float64, lon float64, lat float64) bool {
+	in := false
+	for _, ring := range rings {
+		for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+			a, b := ring[i], ring[j]
+			if (a[1] > lat) != (b[1] > lat) && lon < (b[0]-a[0])*(lat-a[1])/(b[1]-a[1])+a[0] {
+				in = !in
+			}
+		}
+	}
+	return in
+}
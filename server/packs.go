@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const PackKind = "pack"
+
+// MAX_PACK_SIZE caps the size in bytes of an uploaded question pack.
+const MAX_PACK_SIZE = 1 << 20
+
+// questionPack is a custom quiz uploaded through /packs.
+type questionPack struct {
+	Id        string         `json:"id"`
+	Name      string         `json:"name"`
+	Questions []packQuestion `json:"questions"`
+}
+
+// packQuestion is a single question of a pack. When it has fewer
+// distractors than a game needs, the answers to the other questions of the
+// pack fill in.
+type packQuestion struct {
+	Prompt      string   `json:"prompt"`
+	Answer      string   `json:"answer"`
+	Distractors []string `json:"distractors"`
+}
+
+// packSource asks the questions of a pack in a random order, only repeating
+// them once all have been asked.
+type packSource struct {
+	pack    *questionPack
+	options int
+	rng     *rand.Rand
+	queue   []int
+}
+
+// parsePack reads a pack in the given format, either "json" or "csv". CSV
+// packs have a prompt, an answer and any number of distractors per row.
+func parsePack(r io.Reader, format string, name string) (*questionPack, error) {
+	pack := &questionPack{Name: name}
+	switch format {
+	case "json":
+		if err := json.NewDecoder(r).Decode(pack); err != nil {
+			return nil, fmt.Errorf("parsing pack: %v", err)
+		}
+	case "csv":
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		rows, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("parsing pack: %v", err)
+		}
+		if len(rows) > 0 && strings.EqualFold(strings.TrimSpace(rows[0][0]), "prompt") {
+			rows = rows[1:]
+		}
+		for _, row := range rows {
+			q := packQuestion{Prompt: row[0]}
+			if len(row) > 1 {
+				q.Answer = row[1]
+			}
+			if len(row) > 2 {
+				q.Distractors = row[2:]
+			}
+			pack.Questions = append(pack.Questions, q)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported pack format %q", format)
+	}
+	return pack, nil
+}
+
+// validatePack returns every problem that makes the pack unplayable with
+// the given number of options per question.
+func validatePack(pack *questionPack, options int) []error {
+	var problems []error
+	if len(pack.Questions) == 0 {
+		problems = append(problems, fmt.Errorf("pack has no questions"))
+	}
+	answers := make(map[string]bool)
+	for _, q := range pack.Questions {
+		if answer := strings.TrimSpace(q.Answer); answer != "" {
+			answers[answer] = true
+		}
+	}
+	prompts := make(map[string]bool)
+	for i, q := range pack.Questions {
+		prompt := strings.TrimSpace(q.Prompt)
+		answer := strings.TrimSpace(q.Answer)
+		if prompt == "" {
+			problems = append(problems, fmt.Errorf("question %d: empty prompt", i))
+		} else if prompts[prompt] {
+			problems = append(problems, fmt.Errorf("question %d: duplicate prompt %q", i, prompt))
+		}
+		prompts[prompt] = true
+		if answer == "" {
+			problems = append(problems, fmt.Errorf("question %d: empty answer", i))
+		}
+		choices := map[string]bool{answer: true}
+		for _, distractor := range q.Distractors {
+			distractor = strings.TrimSpace(distractor)
+			if distractor == "" {
+				problems = append(problems, fmt.Errorf("question %d: empty distractor", i))
+			} else if choices[distractor] {
+				problems = append(problems, fmt.Errorf("question %d: option %q appears twice", i, distractor))
+			}
+			choices[distractor] = true
+		}
+		for other := range answers {
+			choices[other] = true
+		}
+		if len(choices) < options {
+			problems = append(problems, fmt.Errorf("question %d: only %d distinct options, games need %d", i, len(choices), options))
+		}
+	}
+	return problems
+}
+
+func newPackSource(pack *questionPack, options int, seed int64) *packSource {
+	return &packSource{
+		pack:    pack,
+		options: options,
+		rng:     rand.New(rand.NewSource(seed)),
+	}
+}
+
+func (s *packSource) Next() (question, Validator) {
+	if len(s.queue) == 0 {
+		s.queue = s.rng.Perm(len(s.pack.Questions))
+	}
+	picked := s.pack.Questions[s.queue[0]]
+	s.queue = s.queue[1:]
+	answer := strings.TrimSpace(picked.Answer)
+	options := []string{answer}
+	used := map[string]bool{answer: true}
+	var fillers []string
+	for _, q := range s.pack.Questions {
+		fillers = append(fillers, q.Answer)
+	}
+	s.rng.Shuffle(len(fillers), func(i, j int) { fillers[i], fillers[j] = fillers[j], fillers[i] })
+	distractors := append([]string{}, picked.Distractors...)
+	s.rng.Shuffle(len(distractors), func(i, j int) { distractors[i], distractors[j] = distractors[j], distractors[i] })
+	for _, option := range append(distractors, fillers...) {
+		option = strings.TrimSpace(option)
+		if len(options) == s.options {
+			break
+		}
+		if used[option] {
+			continue
+		}
+		used[option] = true
+		options = append(options, option)
+	}
+	s.rng.Shuffle(len(options), func(i, j int) { options[i], options[j] = options[j], options[i] })
+	q := question{
+		Id:     uuid.New().String(),
+		Kind:   PackKind,
+		Prompt: picked.Prompt,
+	}
+	return q, optionValidator{q.setOptions(options, answer), answer}
+}
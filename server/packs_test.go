@@ -0,0 +1,173 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePack(t *testing.T) {
+	tests := []struct {
+		name      string
+		format    string
+		input     string
+		questions []packQuestion
+		err       bool
+	}{
+		{
+			"csv with header", "csv",
+			"prompt,answer,distractors\nLargest ocean?,Pacific,Atlantic,Indian\n",
+			[]packQuestion{{"Largest ocean?", "Pacific", []string{"Atlantic", "Indian"}}},
+			false,
+		},
+		{
+			"csv header in another case", "csv",
+			" Prompt ,Answer\nLongest river?,Nile\n",
+			[]packQuestion{{"Longest river?", "Nile", nil}},
+			false,
+		},
+		{
+			"csv without header", "csv",
+			"Longest river?,Nile,Amazon\nHighest peak?,Everest\n",
+			[]packQuestion{{"Longest river?", "Nile", []string{"Amazon"}}, {"Highest peak?", "Everest", nil}},
+			false,
+		},
+		{
+			"csv row without answer", "csv",
+			"Longest river?\n",
+			[]packQuestion{{"Longest river?", "", nil}},
+			false,
+		},
+		{
+			"csv with an unclosed quote", "csv",
+			"\"Longest river?,Nile\n",
+			nil,
+			true,
+		},
+		{
+			"json", "json",
+			`{"questions": [{"prompt": "Longest river?", "answer": "Nile", "distractors": ["Amazon"]}]}`,
+			[]packQuestion{{"Longest river?", "Nile", []string{"Amazon"}}},
+			false,
+		},
+		{
+			"broken json", "json",
+			`{"questions": [`,
+			nil,
+			true,
+		},
+		{
+			"unknown format", "xml",
+			"<questions/>",
+			nil,
+			true,
+		},
+	}
+	for _, test := range tests {
+		pack, err := parsePack(strings.NewReader(test.input), test.format, "quiz")
+		if (err != nil) != test.err {
+			t.Errorf("%s: got error %v", test.name, err)
+			continue
+		}
+		if err == nil && !reflect.DeepEqual(pack.Questions, test.questions) {
+			t.Errorf("%s: got %+v, want %+v", test.name, pack.Questions, test.questions)
+		}
+	}
+}
+
+func TestValidatePack(t *testing.T) {
+	tests := []struct {
+		name      string
+		questions []packQuestion
+		options   int
+		problems  []string
+	}{
+		{
+			"enough distractors",
+			[]packQuestion{{"Longest river?", "Nile", []string{"Amazon", "Yangtze", "Danube"}}},
+			4,
+			nil,
+		},
+		{
+			"other answers fill in",
+			[]packQuestion{
+				{"Longest river?", "Nile", []string{"Amazon"}},
+				{"Highest peak?", "Everest", nil},
+				{"Largest desert?", "Sahara", nil},
+				{"Largest lake?", "Caspian Sea", nil},
+			},
+			4,
+			nil,
+		},
+		{
+			"not enough options",
+			[]packQuestion{
+				{"Longest river?", "Nile", []string{"Amazon"}},
+				{"Highest peak?", "Everest", nil},
+			},
+			4,
+			[]string{
+				"question 0: only 3 distinct options, games need 4",
+				"question 1: only 2 distinct options, games need 4",
+			},
+		},
+		{
+			"fewer options needed",
+			[]packQuestion{
+				{"Longest river?", "Nile", []string{"Amazon"}},
+				{"Highest peak?", "Everest", nil},
+			},
+			2,
+			nil,
+		},
+		{
+			"a shared answer counts once",
+			[]packQuestion{
+				{"Longest river?", "Nile", []string{"Amazon"}},
+				{"River through Cairo?", " Nile ", []string{"Amazon"}},
+			},
+			3,
+			[]string{
+				"question 0: only 2 distinct options, games need 3",
+				"question 1: only 2 distinct options, games need 3",
+			},
+		},
+		{
+			"trimmed before comparing",
+			[]packQuestion{
+				{"Longest river?", "Nile", []string{" Nile", "Amazon "}},
+				{" Longest river? ", "Nile", []string{"Amazon"}},
+			},
+			2,
+			[]string{
+				`question 0: option "Nile" appears twice`,
+				`question 1: duplicate prompt "Longest river?"`,
+			},
+		},
+		{
+			"blanks",
+			[]packQuestion{{" ", "", []string{"Amazon", "  "}}},
+			2,
+			[]string{
+				"question 0: empty prompt",
+				"question 0: empty answer",
+				"question 0: empty distractor",
+			},
+		},
+		{
+			"no questions",
+			nil,
+			2,
+			[]string{"pack has no questions"},
+		},
+	}
+	for _, test := range tests {
+		var problems []string
+		for _, problem := range validatePack(&questionPack{Questions: test.questions}, test.options) {
+			problems = append(problems, problem.Error())
+		}
+		if !reflect.DeepEqual(problems, test.problems) {
+			t.Errorf("%s: got %q, want %q", test.name, problems, test.problems)
+		}
+	}
+}
@@ -8,14 +8,16 @@ import (
 
 type Player struct{
 	Id           string
+	Locale       string
 	conn         *websocket.Conn
 	connMux      sync.Mutex
 	readChan     chan websocketMessage
 	stopReadChan chan bool
 }
 
-func(p *Player) New(playerID string, conn *websocket.Conn){
+func(p *Player) New(playerID string, conn *websocket.Conn, locale string){
 	p.Id = playerID
+	p.Locale = locale
 	p.conn = conn
 	p.connMux = sync.Mutex{}
 	p.readChan = make(chan websocketMessage, 4)
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+const (
+	EasyDifficulty   = "easy"
+	MediumDifficulty = "medium"
+	HardDifficulty   = "hard"
+)
+
+// countryPool is the set of countries a game draws its questions from.
+type countryPool struct {
+	countries  []country
+	options    int
+	difficulty string
+	seed       int64
+	rng        *rand.Rand
+	// asked holds the countries already asked about in this game and recent
+	// those the players saw in earlier games. Both are avoided while possible.
+	asked  map[string]bool
+	recent map[string]bool
+	// seen is called with every country a question is asked about.
+	seen func(country)
+	// weight, when set, makes countries it rates higher more likely to be
+	// asked about.
+	weight func(country) float64
+	// revisit, when set, lets countries it returns true for be asked about
+	// again as if they were fresh.
+	revisit func(country) bool
+}
+
+// CountryFilter narrows down the countries a game asks about. Fields left
+// empty don't filter anything.
+type CountryFilter struct {
+	Continents []string `json:"continents"`
+	// SovereignOnly leaves out dependent territories like Åland or Anguilla.
+	SovereignOnly bool `json:"sovereignOnly"`
+	MinPopulation int  `json:"minPopulation"`
+	MaxPopulation int  `json:"maxPopulation"`
+}
+
+// apply returns the countries that pass the filter.
+func (f CountryFilter) apply(countries []country) ([]country, error) {
+	allowed := make(map[string]bool)
+	for _, continent := range f.Continents {
+		if !continents[continent] {
+			return nil, fmt.Errorf("unknown continent %q", continent)
+		}
+		allowed[continent] = true
+	}
+	if f.MinPopulation < 0 || f.MaxPopulation < 0 {
+		return nil, fmt.Errorf("population limits can't be negative")
+	}
+	if f.MaxPopulation > 0 && f.MaxPopulation < f.MinPopulation {
+		return nil, fmt.Errorf("maxPopulation %d is below minPopulation %d", f.MaxPopulation, f.MinPopulation)
+	}
+	var kept []country
+	for _, c := range countries {
+		switch {
+		case len(allowed) > 0 && !allowed[c.Continent]:
+		case f.SovereignOnly && !c.Sovereign:
+		case c.Population < f.MinPopulation:
+		case f.MaxPopulation > 0 && c.Population > f.MaxPopulation:
+		default:
+			kept = append(kept, c)
+		}
+	}
+	return kept, nil
+}
+
+// newCountryPool builds a pool whose questions are fully determined by seed.
+// It fails when there are fewer countries than options to fill.
+func newCountryPool(countries []country, options int, difficulty string, seed int64) (*countryPool, error) {
+	switch difficulty {
+	case "":
+		difficulty = EasyDifficulty
+	case EasyDifficulty, MediumDifficulty, HardDifficulty:
+	default:
+		return nil, fmt.Errorf("unknown difficulty %q", difficulty)
+	}
+	if len(countries) < options {
+		return nil, fmt.Errorf("only %d countries match the filters, not enough for questions with %d options", len(countries), options)
+	}
+	return &countryPool{
+		countries:  countries,
+		options:    options,
+		difficulty: difficulty,
+		seed:       seed,
+		rng:        rand.New(rand.NewSource(seed)),
+		asked:      make(map[string]bool),
+		recent:     make(map[string]bool),
+	}, nil
+}
+
+// pick chooses the country the next question is about.
+func (p *countryPool) pick() country {
+	c, _ := p.pickWhere(func(country) bool { return true })
+	return c
+}
+
+// has tells whether any country of the pool is accepted by ok.
+func (p *countryPool) has(ok func(country) bool) bool {
+	for _, c := range p.countries {
+		if ok(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// byCode indexes the countries of the pool by their alpha3 code.
+func (p *countryPool) byCode() map[string]country {
+	byCode := make(map[string]country)
+	for _, c := range p.countries {
+		byCode[c.Alpha3] = c
+	}
+	return byCode
+}
+
+// pickWhere chooses the country the next question is about among those
+// accepted by ok. Countries already asked about, then those the players saw
+// recently, are only picked once there's nothing else left, unless revisit
+// brings them back. It returns false when no country is accepted.
+func (p *countryPool) pickWhere(ok func(country) bool) (country, bool) {
+	var fresh, recent, asked []country
+	for _, c := range p.countries {
+		switch {
+		case !ok(c):
+		case p.revisit != nil && p.revisit(c):
+			fresh = append(fresh, c)
+		case p.asked[c.Alpha3]:
+			asked = append(asked, c)
+		case p.recent[c.Alpha3]:
+			recent = append(recent, c)
+		default:
+			fresh = append(fresh, c)
+		}
+	}
+	for _, eligible := range [][]country{fresh, recent, asked} {
+		if len(eligible) == 0 {
+			continue
+		}
+		c := eligible[p.choose(eligible)]
+		p.asked[c.Alpha3] = true
+		if p.seen != nil {
+			p.seen(c)
+		}
+		return c, true
+	}
+	return country{}, false
+}
+
+// choose returns the index of one of the eligible countries.
+func (p *countryPool) choose(eligible []country) int {
+	if p.weight == nil {
+		return p.rng.Intn(len(eligible))
+	}
+	weights := make([]float64, len(eligible))
+	total := 0.0
+	for i, c := range eligible {
+		weights[i] = p.weight(c)
+		total += weights[i]
+	}
+	target := p.rng.Float64() * total
+	for i, w := range weights {
+		target -= w
+		if target < 0 {
+			return i
+		}
+	}
+	return len(eligible) - 1
+}
+
+// draw picks the country a question is about and the countries offered as
+// options, the answer included. label is what the player sees for each
+// option; two options never share the same label. Countries for which
+// alsoRight returns true would be right answers too, so they aren't offered.
+func (p *countryPool) draw(label func(country) string, alsoRight func(answer country, c country) bool) (country, []country) {
+	answer, ok := p.pickWhere(p.drawable(label, alsoRight))
+	if !ok {
+		// ruled out when the game is created, kept as a guard
+		answer = p.pick()
+	}
+	return answer, p.withDistractors(answer, answer, label, func(c country) bool {
+		return alsoRight(answer, c)
+	})
+}
+
+// drawable accepts the countries draw can fill every option of a question
+// about.
+func (p *countryPool) drawable(label func(country) string, alsoRight func(answer country, c country) bool) func(country) bool {
+	return func(answer country) bool {
+		return p.canFill(answer, answer, label, func(c country) bool {
+			return alsoRight(answer, c)
+		})
+	}
+}
+
+// canFill tells whether withDistractors finds enough distractors to fill
+// every option of a question about subject.
+func (p *countryPool) canFill(subject country, answer country, label func(country) string, exclude func(country) bool) bool {
+	used := map[string]bool{label(answer): true}
+	for _, c := range p.countries {
+		if len(used) == p.options {
+			break
+		}
+		if c.Alpha3 == subject.Alpha3 || c.Alpha3 == answer.Alpha3 || used[label(c)] || (exclude != nil && exclude(c)) {
+			continue
+		}
+		used[label(c)] = true
+	}
+	return len(used) == p.options
+}
+
+// withDistractors returns the answer to a question about subject shuffled
+// among distractors picked for the pool's difficulty. Countries for which
+// exclude returns true are never offered as distractors.
+func (p *countryPool) withDistractors(subject country, answer country, label func(country) string, exclude func(country) bool) []country {
+	picked := []country{answer}
+	used := map[string]bool{label(answer): true}
+	for _, candidates := range p.distractorTiers(subject, answer, label) {
+		p.rng.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+		for _, c := range candidates {
+			if len(picked) == p.options {
+				break
+			}
+			if used[label(c)] || (exclude != nil && exclude(c)) {
+				continue
+			}
+			used[label(c)] = true
+			picked = append(picked, c)
+		}
+	}
+	if len(picked) < p.options {
+		// sources only ask what they can fill, so this shouldn't happen
+		fmt.Printf("Only %d of %d options for a question about %s\n", len(picked), p.options, subject.Name)
+	}
+	p.rng.Shuffle(len(picked), func(i, j int) { picked[i], picked[j] = picked[j], picked[i] })
+	return picked
+}
+
+// distractorTiers groups the candidate distractors from most to least
+// preferred for the pool's difficulty, by how close they are to subject and
+// how much their label looks like the answer's. Later tiers only fill the
+// options the earlier ones couldn't.
+func (p *countryPool) distractorTiers(subject country, answer country, label func(country) string) [][]country {
+	var subregion, continent, others []country
+	for _, c := range p.countries {
+		switch {
+		case c.Alpha3 == answer.Alpha3 || c.Alpha3 == subject.Alpha3:
+		case c.Subregion == subject.Subregion:
+			subregion = append(subregion, c)
+		case c.Continent == subject.Continent:
+			continent = append(continent, c)
+		default:
+			others = append(others, c)
+		}
+	}
+	switch p.difficulty {
+	case MediumDifficulty:
+		return [][]country{append(subregion, continent...), others}
+	case HardDifficulty:
+		lookalikes := append(append([]country{}, continent...), others...)
+		sort.SliceStable(lookalikes, func(i, j int) bool {
+			return levenshtein(label(lookalikes[i]), label(answer)) < levenshtein(label(lookalikes[j]), label(answer))
+		})
+		if len(lookalikes) > p.options {
+			lookalikes = lookalikes[:p.options]
+		}
+		return [][]country{append(subregion, lookalikes...), continent, others}
+	default:
+		return [][]country{append(append(subregion, continent...), others...)}
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	PRACTICE_ROUNDS = 10
+	// NEW_CARDS_PER_DAY caps how many countries a player meets for the first
+	// time each day, so that reviews don't pile up.
+	NEW_CARDS_PER_DAY = 10
+	DATE_LAYOUT       = "2006-01-02"
+)
+
+type PracticeRequest struct {
+	Player     string `json:"player"`
+	Rounds     int    `json:"rounds"`
+	Mode       string `json:"mode"`
+	Difficulty string `json:"difficulty"`
+}
+
+type PracticeResponse struct {
+	GameID int `json:"gameID"`
+	// Due is how many countries the player had to review today.
+	Due int `json:"due"`
+}
+
+// card is a player's progress on a country, scheduled with the SM-2
+// algorithm: every good answer pushes the next review further away, by a
+// factor that shrinks each time the player struggles.
+type card struct {
+	Repetitions int     `json:"repetitions"`
+	Interval    int     `json:"interval"`
+	Ease        float64 `json:"ease"`
+	Due         string  `json:"due"`
+	Introduced  string  `json:"introduced"`
+}
+
+// practiceStore keeps the cards of every player, saved to a JSON file after
+// each review.
+type practiceStore struct {
+	mux   sync.Mutex
+	path  string
+	decks map[string]map[string]*card
+}
+
+// practiceDeck schedules the questions of a player's practice game and
+// reviews their cards as they answer.
+type practiceDeck struct {
+	store  *practiceStore
+	player string
+}
+
+// loadPracticeStore reads the cards saved at p. A missing file is an empty store.
+func loadPracticeStore(p string) (*practiceStore, error) {
+	store := &practiceStore{path: p, decks: make(map[string]map[string]*card)}
+	if err := readJSONFile(p, &store.decks); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+func (s *practiceStore) deck(player string) practiceDeck {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if s.decks[player] == nil {
+		s.decks[player] = make(map[string]*card)
+	}
+	return practiceDeck{s, player}
+}
+
+// due counts the cards to review today.
+func (d practiceDeck) due() int {
+	d.store.mux.Lock()
+	defer d.store.mux.Unlock()
+	today := time.Now().Format(DATE_LAYOUT)
+	due := 0
+	for _, c := range d.store.decks[d.player] {
+		if c.Due <= today {
+			due++
+		}
+	}
+	return due
+}
+
+// weight steers the pool towards the cards due for review, the most overdue
+// first, then towards new countries while the day's allowance lasts. Cards
+// reviewed recently only come up once there's nothing else to ask.
+func (d practiceDeck) weight(c country) float64 {
+	d.store.mux.Lock()
+	defer d.store.mux.Unlock()
+	now := time.Now()
+	today := now.Format(DATE_LAYOUT)
+	cards := d.store.decks[d.player]
+	cd, ok := cards[c.Alpha3]
+	if !ok {
+		introduced := 0
+		for _, other := range cards {
+			if other.Introduced == today {
+				introduced++
+			}
+		}
+		if introduced < NEW_CARDS_PER_DAY {
+			return 1e3
+		}
+		return 1e-3
+	}
+	if cd.Due > today {
+		return 1e-3
+	}
+	due, _ := time.Parse(DATE_LAYOUT, cd.Due)
+	overdue := math.Max(0, now.Sub(due).Hours()/24)
+	return 1e6 * (1 + overdue)
+}
+
+// record reviews the card of c, grading the answer the way SM-2 expects.
+func (d practiceDeck) record(c country, credit float64, fractionOfTime float64) {
+	d.store.mux.Lock()
+	defer d.store.mux.Unlock()
+	now := time.Now()
+	cards := d.store.decks[d.player]
+	cd, ok := cards[c.Alpha3]
+	if !ok {
+		cd = &card{Ease: 2.5, Introduced: now.Format(DATE_LAYOUT)}
+		cards[c.Alpha3] = cd
+	}
+	cd.review(answerQuality(credit, fractionOfTime), now)
+	if err := writeJSONFile(d.store.path, d.store.decks); err != nil {
+		fmt.Println("Error saving practice:", err)
+	}
+}
+
+// answerQuality rates an answer from 0, for not answering at all, to 5, for
+// a right and quick one.
+func answerQuality(credit float64, fractionOfTime float64) int {
+	switch {
+	case credit >= 1 && fractionOfTime < 1.0/3:
+		return 5
+	case credit >= 1 && fractionOfTime < 2.0/3:
+		return 4
+	case credit >= 0.5:
+		return 3
+	case credit > 0:
+		return 2
+	case fractionOfTime < 1:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (cd *card) review(quality int, now time.Time) {
+	if quality < 3 {
+		cd.Repetitions = 0
+		cd.Interval = 1
+	} else {
+		switch cd.Repetitions {
+		case 0:
+			cd.Interval = 1
+		case 1:
+			cd.Interval = 6
+		default:
+			cd.Interval = int(math.Round(float64(cd.Interval) * cd.Ease))
+		}
+		cd.Repetitions++
+	}
+	miss := float64(5 - quality)
+	cd.Ease = math.Max(1.3, cd.Ease+0.1-miss*(0.08+miss*0.02))
+	cd.Due = now.AddDate(0, 0, cd.Interval).Format(DATE_LAYOUT)
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCardReview(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		before  card
+		quality int
+		after   card
+	}{
+		{"first good answer", card{Ease: 2.5}, 4, card{Repetitions: 1, Interval: 1, Ease: 2.5, Due: "2024-03-02"}},
+		{"second good answer", card{Repetitions: 1, Interval: 1, Ease: 2.5}, 4, card{Repetitions: 2, Interval: 6, Ease: 2.5, Due: "2024-03-07"}},
+		{"interval grows with ease", card{Repetitions: 2, Interval: 6, Ease: 2.5}, 5, card{Repetitions: 3, Interval: 15, Ease: 2.6, Due: "2024-03-16"}},
+		{"hesitant answer lowers ease", card{Repetitions: 2, Interval: 6, Ease: 2.5}, 3, card{Repetitions: 3, Interval: 15, Ease: 2.36, Due: "2024-03-16"}},
+		{"miss starts over", card{Repetitions: 4, Interval: 40, Ease: 2.5}, 1, card{Repetitions: 0, Interval: 1, Ease: 1.96, Due: "2024-03-02"}},
+		{"ease doesn't drop below 1.3", card{Repetitions: 1, Interval: 1, Ease: 1.3}, 0, card{Repetitions: 0, Interval: 1, Ease: 1.3, Due: "2024-03-02"}},
+	}
+	for _, test := range tests {
+		cd := test.before
+		cd.review(test.quality, now)
+		if cd.Repetitions != test.after.Repetitions || cd.Interval != test.after.Interval || cd.Due != test.after.Due {
+			t.Errorf("%s: got %+v, want %+v", test.name, cd, test.after)
+		}
+		if diff := cd.Ease - test.after.Ease; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("%s: got ease %v, want %v", test.name, cd.Ease, test.after.Ease)
+		}
+	}
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+const (
+	CapitalKind        = "capital"
+	ReverseCapitalKind = "reverseCapital"
+	FreeTextKind       = "freeText"
+	CoordinatesKind    = "coordinates"
+	MixedMode          = "mixed"
+)
+
+// DEFAULT_TOLERANCE is the number of typos forgiven in free text answers
+// when the game doesn't set one.
+const DEFAULT_TOLERANCE = 2
+
+// QuestionSource produces the questions of a game together with the
+// validator used to grade the answers to each of them.
+type QuestionSource interface {
+	Next() (question, Validator)
+}
+
+// askableSource is implemented by sources that can't ask about every
+// country, so that games whose filters leave them nothing to ask about are
+// refused when they're created.
+type askableSource interface {
+	askable() bool
+}
+
+// Validator grades the answers to the question it was generated with.
+type Validator interface {
+	// Grade returns the share of the question's points the answer earns, from 0 to 1.
+	Grade(a answer) float64
+	// Solution is the right answer as shown to the players.
+	Solution() string
+}
+
+// optionAnswerer is implemented by validators of questions answered by
+// picking an option, so the right one can be shown as the player saw it.
+type optionAnswerer interface {
+	AnswerId() string
+}
+
+// revealer is implemented by validators with facts to show the players once
+// they have answered, written in each player's locale.
+type revealer interface {
+	Reveal(locale string) string
+}
+
+type capitalSource struct {
+	pool *countryPool
+}
+
+type reverseCapitalSource struct {
+	pool *countryPool
+}
+
+type freeTextSource struct {
+	pool      *countryPool
+	tolerance int
+}
+
+type coordinatesSource struct {
+	pool *countryPool
+}
+
+// mixedSource asks each question from one of its sources chosen at random.
+// The choice has its own generator so that games seeded alike ask the same
+// kinds of questions in the same order, whatever countries they pick.
+type mixedSource struct {
+	sources []QuestionSource
+	kinds   *rand.Rand
+}
+
+// optionValidator grades questions answered by picking one of their options.
+// It compares option IDs, so it doesn't matter what language the options were
+// shown in.
+type optionValidator struct {
+	answerId string
+	solution string
+}
+
+// textValidator grades typed answers. Answers within tolerance edits of an
+// accepted spelling get full credit and those up to twice as far get partial
+// credit. accepted holds the spellings normalized.
+type textValidator struct {
+	spellings []string
+	accepted  []string
+	tolerance int
+}
+
+// newQuestionSource builds the source for the mode requested in CreateGameRequest.
+// It fails when the pool has nothing to ask in a mode requested, while the
+// default mix leaves such modes out.
+func newQuestionSource(request CreateGameRequest, pool *countryPool) (QuestionSource, error) {
+	if request.Mode != MixedMode {
+		source, err := newSingleSource(request.Mode, request, pool)
+		if err != nil {
+			return nil, err
+		}
+		return source, checkAskable(request.Mode, source)
+	}
+	modes := request.Modes
+	if len(modes) == 0 {
+		modes = []string{CapitalKind, ReverseCapitalKind, BordersKind, BorderCountKind, CompareKind}
+	}
+	mixed := &mixedSource{kinds: rand.New(rand.NewSource(pool.seed))}
+	for _, mode := range modes {
+		if mode == MixedMode {
+			return nil, fmt.Errorf("can't mix the %q mode", mode)
+		}
+		source, err := newSingleSource(mode, request, pool)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkAskable(mode, source); err != nil {
+			if len(request.Modes) > 0 {
+				return nil, err
+			}
+			continue
+		}
+		mixed.sources = append(mixed.sources, source)
+	}
+	return mixed, nil
+}
+
+// checkAskable fails when source has nothing to ask about in its pool.
+func checkAskable(mode string, source QuestionSource) error {
+	if s, ok := source.(askableSource); ok && !s.askable() {
+		return fmt.Errorf("none of the countries matching the filters suit %s questions", mode)
+	}
+	return nil
+}
+
+func newSingleSource(mode string, request CreateGameRequest, pool *countryPool) (QuestionSource, error) {
+	switch mode {
+	case "", CapitalKind:
+		return &capitalSource{pool}, nil
+	case ReverseCapitalKind:
+		return &reverseCapitalSource{pool}, nil
+	case BordersKind:
+		return &bordersSource{pool}, nil
+	case BorderCountKind:
+		return &borderCountSource{pool}, nil
+	case CompareKind:
+		return &compareSource{pool}, nil
+	case CoordinatesKind:
+		return &coordinatesSource{pool}, nil
+	case OutlineKind:
+		return &outlineSource{pool}, nil
+	case FlagKind:
+		return &flagSource{pool}, nil
+	case TrueFalseKind:
+		return &trueFalseSource{pool}, nil
+	case SelectAllKind:
+		return &selectAllSource{pool}, nil
+	case OrderingKind:
+		return &orderingSource{pool}, nil
+	case FreeTextKind:
+		tolerance := DEFAULT_TOLERANCE
+		if request.Tolerance != nil {
+			tolerance = *request.Tolerance
+		}
+		if tolerance < 0 {
+			return nil, fmt.Errorf("tolerance can't be negative")
+		}
+		return &freeTextSource{pool, tolerance}, nil
+	default:
+		return nil, fmt.Errorf("unknown game mode %q", mode)
+	}
+}
+
+func capitalOf(c country) string {
+	return c.Capital
+}
+
+func nameOf(c country) string {
+	return c.Name
+}
+
+// setOptions gives each option an ID and returns the ID of answer.
+func (q *question) setOptions(options []string, answer string) string {
+	q.Options = options
+	q.OptionIds = make([]string, len(options))
+	answerId := ""
+	for i, option := range options {
+		q.OptionIds[i] = strconv.Itoa(i + 1)
+		if option == answer {
+			answerId = q.OptionIds[i]
+		}
+	}
+	return answerId
+}
+
+// setCountryOptions offers the name or capital of each picked country and
+// returns the ID of answer's.
+func (q *question) setCountryOptions(picked []country, field string, answer country) string {
+	options := make([]string, len(picked))
+	q.optionCodes = make([]string, len(picked))
+	for i, c := range picked {
+		options[i] = c.text(field, DEFAULT_LOCALE)
+		q.optionCodes[i] = c.Alpha3
+	}
+	q.optionField = field
+	return q.setOptions(options, answer.text(field, DEFAULT_LOCALE))
+}
+
+func (s *capitalSource) Next() (question, Validator) {
+	answer, picked := s.pool.draw(capitalOf, sharesCapital)
+	q := question{
+		Id:      uuid.New().String(),
+		Kind:    CapitalKind,
+		Country: answer.Name,
+		subject: answer.Alpha3,
+	}
+	return q, optionValidator{q.setCountryOptions(picked, CapitalField, answer), answer.Capital}
+}
+
+func (s *capitalSource) askable() bool {
+	return s.pool.has(s.pool.drawable(capitalOf, sharesCapital))
+}
+
+func (s *reverseCapitalSource) askable() bool {
+	return s.pool.has(s.pool.drawable(nameOf, capitalOfBoth))
+}
+
+// sharesCapital accepts the countries whose capital is one of answer's too.
+func sharesCapital(answer country, c country) bool {
+	return answer.hasCapital(c.Capital)
+}
+
+// capitalOfBoth accepts the countries with answer's capital among theirs.
+func capitalOfBoth(answer country, c country) bool {
+	return c.hasCapital(answer.Capital)
+}
+
+func (s *reverseCapitalSource) Next() (question, Validator) {
+	// Kingston is the capital of both Jamaica and Norfolk Island
+	answer, picked := s.pool.draw(nameOf, capitalOfBoth)
+	q := question{
+		Id:      uuid.New().String(),
+		Kind:    ReverseCapitalKind,
+		Capital: answer.Capital,
+		subject: answer.Alpha3,
+	}
+	return q, optionValidator{q.setCountryOptions(picked, NameField, answer), answer.Name}
+}
+
+func (s *freeTextSource) Next() (question, Validator) {
+	answer := s.pool.pick()
+	q := question{
+		Id:      uuid.New().String(),
+		Kind:    FreeTextKind,
+		Country: answer.Name,
+		subject: answer.Alpha3,
+	}
+	spellings := append(answer.capitals(), answer.CapitalAltSpellings...)
+	for _, t := range answer.Translations {
+		if t.Capital != "" {
+			spellings = append(spellings, t.Capital)
+		}
+		spellings = append(spellings, t.OtherCapitals...)
+	}
+	return q, newTextValidator(spellings, s.tolerance)
+}
+
+// Next asks for the location of either a capital or a whole country. Guesses
+// for a country count from its edge rather than its center.
+func (s *coordinatesSource) Next() (question, Validator) {
+	answer := s.pool.pick()
+	q := question{
+		Id:      uuid.New().String(),
+		Kind:    CoordinatesKind,
+		subject: answer.Alpha3,
+	}
+	if s.pool.rng.Intn(2) == 0 {
+		q.Capital = answer.Capital
+		return q, distanceValidator{answer.CapitalLat, answer.CapitalLon, 0, answer, CapitalField}
+	}
+	q.Country = answer.Name
+	return q, distanceValidator{answer.Lat, answer.Lon, math.Sqrt(answer.Area / math.Pi), answer, NameField}
+}
+
+func (s *mixedSource) Next() (question, Validator) {
+	return s.sources[s.kinds.Intn(len(s.sources))].Next()
+}
+
+func (v optionValidator) Grade(a answer) float64 {
+	if a.OptionId == v.answerId {
+		return 1
+	}
+	return 0
+}
+
+func (v optionValidator) Solution() string {
+	return v.solution
+}
+
+func (v optionValidator) AnswerId() string {
+	return v.answerId
+}
+
+func newTextValidator(spellings []string, tolerance int) textValidator {
+	accepted := make([]string, len(spellings))
+	for i, spelling := range spellings {
+		accepted[i] = normalize(spelling)
+	}
+	return textValidator{spellings, accepted, tolerance}
+}
+
+func (v textValidator) Grade(a answer) float64 {
+	credit, _ := v.closest(a)
+	return credit
+}
+
+// closest returns the credit of the answer and the accepted spelling that
+// earned it, the first one when none did.
+func (v textValidator) closest(a answer) (float64, string) {
+	given := normalize(a.Capital)
+	best, closest := 0.0, v.spellings[0]
+	for i, spelling := range v.accepted {
+		// short names get fewer typos, otherwise "Lima" would accept "Lome"
+		tolerance := minInt(v.tolerance, len([]rune(spelling))/5)
+		distance := levenshtein(given, spelling)
+		credit := 0.0
+		if distance <= tolerance {
+			credit = 1
+		} else if distance <= 2*tolerance {
+			credit = 1 - float64(distance-tolerance)/float64(tolerance+1)
+		}
+		if credit > best {
+			best, closest = credit, v.spellings[i]
+		}
+	}
+	return best, closest
+}
+
+func (v textValidator) Solution() string {
+	return v.spellings[0]
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSameSeedSameQuestions(t *testing.T) {
+	fetchCapitals("assets/countries.json")
+	fetchOutlines()
+	fetchFlags()
+	modes := []string{
+		CapitalKind,
+		ReverseCapitalKind,
+		BordersKind,
+		BorderCountKind,
+		CompareKind,
+		CoordinatesKind,
+		OutlineKind,
+		FlagKind,
+		TrueFalseKind,
+		SelectAllKind,
+		OrderingKind,
+		FreeTextKind,
+		MixedMode,
+	}
+	for _, mode := range modes {
+		var runs [2][]question
+		for run := range runs {
+			pool, err := newCountryPool(capitals, DEFAULT_OPTIONS, HardDifficulty, 42)
+			if err != nil {
+				t.Fatal(err)
+			}
+			source, err := newQuestionSource(CreateGameRequest{Mode: mode}, pool)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 20; i++ {
+				q, _ := source.Next()
+				if mode != MixedMode && q.Kind != mode {
+					t.Fatalf("%s: asked a %s question instead", mode, q.Kind)
+				}
+				// IDs are random so that games can't be told apart by them
+				q.Id = ""
+				runs[run] = append(runs[run], q)
+			}
+		}
+		for i := range runs[0] {
+			if !reflect.DeepEqual(runs[0][i], runs[1][i]) {
+				t.Errorf("%s: question %d differs between runs: %+v and %+v", mode, i, runs[0][i], runs[1][i])
+				break
+			}
+		}
+	}
+}
+
+func TestModesTheFiltersRuleOut(t *testing.T) {
+	fetchCapitals("assets/countries.json")
+	fetchOutlines()
+	fetchFlags()
+	tests := []struct {
+		mode    string
+		filter  CountryFilter
+		askable bool
+	}{
+		{FlagKind, CountryFilter{Continents: []string{"Oceania"}}, false},
+		{FlagKind, CountryFilter{Continents: []string{"Europe"}}, true},
+		{BordersKind, CountryFilter{Continents: []string{"Oceania"}}, false},
+		{BordersKind, CountryFilter{Continents: []string{"South America"}}, true},
+		{OutlineKind, CountryFilter{Continents: []string{"North America"}, MaxPopulation: 1000000}, false},
+		{SelectAllKind, CountryFilter{Continents: []string{"South America"}}, false},
+		{SelectAllKind, CountryFilter{Continents: []string{"Africa"}}, true},
+		{CompareKind, CountryFilter{Continents: []string{"Oceania"}}, true},
+		{OrderingKind, CountryFilter{Continents: []string{"Oceania"}}, true},
+	}
+	for _, test := range tests {
+		countries, err := test.filter.apply(capitals)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pool, err := newCountryPool(countries, DEFAULT_OPTIONS, EasyDifficulty, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = newQuestionSource(CreateGameRequest{Mode: test.mode}, pool)
+		if askable := err == nil; askable != test.askable {
+			t.Errorf("%s in %+v: got error %v", test.mode, test.filter, err)
+		}
+	}
+}
+
+func TestQuestionsFillEveryOption(t *testing.T) {
+	fetchCapitals("assets/countries.json")
+	fetchOutlines()
+	fetchFlags()
+	southAmerica, err := CountryFilter{Continents: []string{"South America"}}.apply(capitals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mode := range []string{CapitalKind, ReverseCapitalKind, BordersKind, BorderCountKind, OutlineKind, FlagKind} {
+		for options := MIN_OPTIONS; options <= MAX_OPTIONS; options++ {
+			pool, err := newCountryPool(southAmerica, options, HardDifficulty, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			source, err := newQuestionSource(CreateGameRequest{Mode: mode}, pool)
+			if err != nil {
+				continue
+			}
+			for i := 0; i < 30; i++ {
+				if q, _ := source.Next(); len(q.Options) != options {
+					t.Errorf("%s with %d options: %q", mode, options, q.Options)
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestOneRightCapitalPerQuestion(t *testing.T) {
+	countries := []country{
+		{Name: "Bolivia", Alpha3: "BOL", Capital: "Sucre", OtherCapitals: []string{"La Paz"}},
+		{Name: "Paceland", Alpha3: "PAC", Capital: "La Paz"},
+		{Name: "Jamaica", Alpha3: "JAM", Capital: "Kingston"},
+		{Name: "Norfolk Island", Alpha3: "NFK", Capital: "Kingston"},
+		{Name: "Peru", Alpha3: "PER", Capital: "Lima"},
+		{Name: "Chile", Alpha3: "CHL", Capital: "Santiago"},
+		{Name: "Ecuador", Alpha3: "ECU", Capital: "Quito"},
+		{Name: "Uruguay", Alpha3: "URY", Capital: "Montevideo"},
+	}
+	byName := make(map[string]country)
+	byCode := make(map[string]country)
+	for _, c := range countries {
+		byName[c.Name] = c
+		byCode[c.Alpha3] = c
+	}
+	pool, err := newCountryPool(countries, DEFAULT_OPTIONS, EasyDifficulty, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		source QuestionSource
+		right  func(q question, option string) bool
+	}{
+		{&capitalSource{pool}, func(q question, option string) bool {
+			return byCode[q.subject].hasCapital(option)
+		}},
+		{&reverseCapitalSource{pool}, func(q question, option string) bool {
+			return byName[option].hasCapital(q.Capital)
+		}},
+	}
+	for _, test := range tests {
+		for i := 0; i < 200; i++ {
+			q, _ := test.source.Next()
+			right := 0
+			for _, option := range q.Options {
+				if test.right(q, option) {
+					right++
+				}
+			}
+			if right != 1 {
+				t.Errorf("%s question about %s has %d right options: %q", q.Kind, q.subject, right, q.Options)
+			}
+		}
+	}
+}
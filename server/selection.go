@@ -0,0 +1,174 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TrueFalseKind = "trueFalse"
+	SelectAllKind = "selectAll"
+)
+
+const (
+	TRUE_OPTION  = "True"
+	FALSE_OPTION = "False"
+)
+
+// trueFalseSource states that a city is the capital of a country and asks
+// whether that's right. Wrong statements name the capital of a country the
+// pool's difficulty would offer as a distractor.
+type trueFalseSource struct {
+	pool *countryPool
+}
+
+// selectAllSource offers countries and asks which of them are in a continent,
+// or in a subregion when the pool doesn't reach outside the continent.
+type selectAllSource struct {
+	pool *countryPool
+}
+
+// selectionValidator grades answers picking any number of options. Each
+// right pick earns a share of the points and each wrong pick takes one away.
+type selectionValidator struct {
+	answerIds []string
+	solution  string
+}
+
+// selectionsAnswerer is implemented by validators of questions with several
+// right options, so they can be shown as the player saw them.
+type selectionsAnswerer interface {
+	AnswerIds() []string
+}
+
+func (s *trueFalseSource) Next() (question, Validator) {
+	answer, picked := s.pool.draw(capitalOf, func(answer country, c country) bool {
+		return answer.hasCapital(c.Capital)
+	})
+	stated := answer
+	if s.pool.rng.Intn(2) == 0 {
+		for _, c := range picked {
+			if c.Alpha3 != answer.Alpha3 {
+				stated = c
+				break
+			}
+		}
+	}
+	q := question{
+		Id:             uuid.New().String(),
+		Kind:           TrueFalseKind,
+		Country:        answer.Name,
+		Capital:        stated.Capital,
+		subject:        answer.Alpha3,
+		capitalSubject: stated.Alpha3,
+	}
+	truth := FALSE_OPTION
+	if stated.Alpha3 == answer.Alpha3 {
+		truth = TRUE_OPTION
+	}
+	return q, optionValidator{q.setOptions([]string{TRUE_OPTION, FALSE_OPTION}, truth), truth}
+}
+
+func (s *selectAllSource) Next() (question, Validator) {
+	subject := s.pool.pick()
+	for _, regionOf := range []func(country) string{continentOf, subregionOf} {
+		region := regionOf(subject)
+		var inside, outside []country
+		for _, c := range s.pool.countries {
+			switch {
+			case c.Alpha3 == subject.Alpha3:
+			case regionOf(c) == region:
+				inside = append(inside, c)
+			default:
+				outside = append(outside, c)
+			}
+		}
+		if len(outside) == 0 {
+			continue
+		}
+		// at least one option is right and one wrong, the rest is up to chance
+		wrong := minInt(1+s.pool.rng.Intn(s.pool.options-1), len(outside))
+		right := s.pool.options - wrong
+		if right-1 > len(inside) {
+			right = len(inside) + 1
+			wrong = s.pool.options - right
+		}
+		shuffle := func(countries []country) {
+			s.pool.rng.Shuffle(len(countries), func(i, j int) { countries[i], countries[j] = countries[j], countries[i] })
+		}
+		shuffle(inside)
+		shuffle(outside)
+		picked := append(append([]country{subject}, inside[:right-1]...), outside[:wrong]...)
+		shuffle(picked)
+
+		q := question{
+			Id:      uuid.New().String(),
+			Kind:    SelectAllKind,
+			Region:  region,
+			subject: subject.Alpha3,
+		}
+		q.setCountryOptions(picked, NameField, subject)
+		var answerIds, names []string
+		for i, c := range picked {
+			if regionOf(c) == region {
+				answerIds = append(answerIds, q.OptionIds[i])
+				names = append(names, c.Name)
+			}
+		}
+		return q, selectionValidator{answerIds, strings.Join(names, ", ")}
+	}
+	// the pool is all in one subregion, ruled out by askable and kept as a
+	// guard
+	return (&capitalSource{s.pool}).Next()
+}
+
+// askable tells whether the pool spans more than one subregion, so that some
+// options are outside the subject's.
+func (s *selectAllSource) askable() bool {
+	subregions := make(map[string]bool)
+	for _, c := range s.pool.countries {
+		subregions[c.Subregion] = true
+	}
+	return len(subregions) > 1
+}
+
+func continentOf(c country) string {
+	return c.Continent
+}
+
+func subregionOf(c country) string {
+	return c.Subregion
+}
+
+func (v selectionValidator) Grade(a answer) float64 {
+	right := make(map[string]bool)
+	for _, id := range v.answerIds {
+		right[id] = true
+	}
+	picked := make(map[string]bool)
+	hits := 0
+	for _, id := range a.Selections {
+		if picked[id] {
+			continue
+		}
+		picked[id] = true
+		if right[id] {
+			hits++
+		} else {
+			hits--
+		}
+	}
+	if hits <= 0 {
+		return 0
+	}
+	return float64(hits) / float64(len(v.answerIds))
+}
+
+func (v selectionValidator) Solution() string {
+	return v.solution
+}
+
+func (v selectionValidator) AnswerIds() []string {
+	return v.answerIds
+}
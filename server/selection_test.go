@@ -0,0 +1,30 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSelectionValidatorGrade(t *testing.T) {
+	v := selectionValidator{answerIds: []string{"a", "b", "c"}}
+	tests := []struct {
+		name       string
+		selections []string
+		credit     float64
+	}{
+		{"all right", []string{"a", "b", "c"}, 1},
+		{"in another order", []string{"c", "a", "b"}, 1},
+		{"some right", []string{"a", "b"}, 2.0 / 3},
+		{"a wrong one cancels a right one", []string{"a", "b", "x"}, 1.0 / 3},
+		{"everything ticked", []string{"a", "b", "c", "x", "y"}, 1.0 / 3},
+		{"more wrong than right", []string{"a", "x", "y"}, 0},
+		{"only wrong", []string{"x"}, 0},
+		{"nothing", nil, 0},
+		{"repeats count once", []string{"a", "a", "a"}, 1.0 / 3},
+	}
+	for _, test := range tests {
+		if credit := v.Grade(answer{Selections: test.selections}); math.Abs(credit-test.credit) > 1e-9 {
+			t.Errorf("%s: got %v, want %v", test.name, credit, test.credit)
+		}
+	}
+}
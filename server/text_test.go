@@ -0,0 +1,98 @@
+package main
+
+import "testing"
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		distance int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"lima", "lima", 0},
+		{"lima", "lome", 2},
+		{"kitten", "sitting", 3},
+		{"bern", "bren", 2},
+		{"bogotá", "bogota", 1},
+	}
+	for _, test := range tests {
+		if distance := levenshtein(test.a, test.b); distance != test.distance {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", test.a, test.b, distance, test.distance)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		s, normalized string
+	}{
+		{"Paris", "paris"},
+		{"  São   Tomé ", "sao tome"},
+		{"Reykjavík", "reykjavik"},
+		{"Tórshavn", "torshavn"},
+		{"København", "kobenhavn"},
+		{"Saint John's", "st john s"},
+		{"St. John's", "st john s"},
+		{"Port-au-Prince", "port au prince"},
+		{"Łódź", "lodz"},
+		{"", ""},
+	}
+	for _, test := range tests {
+		if normalized := normalize(test.s); normalized != test.normalized {
+			t.Errorf("normalize(%q) = %q, want %q", test.s, normalized, test.normalized)
+		}
+	}
+}
+
+func TestTextValidatorGrade(t *testing.T) {
+	v := newTextValidator([]string{"Reykjavík", "Reykjavik"}, 2)
+	tests := []struct {
+		given  string
+		credit float64
+	}{
+		{"Reykjavík", 1},
+		{"reykjavik", 1},
+		{" REYKJAVIK! ", 1},
+		{"Reikjavik", 1},
+		{"Rekyjavik", 0.5},
+		{"Reikiavick", 0},
+		{"Oslo", 0},
+		{"", 0},
+	}
+	for _, test := range tests {
+		if credit := v.Grade(answer{Capital: test.given}); credit != test.credit {
+			t.Errorf("Grade(%q) = %v, want %v", test.given, credit, test.credit)
+		}
+	}
+}
+
+func TestShortNamesGetFewerTypos(t *testing.T) {
+	v := newTextValidator([]string{"Lima"}, 2)
+	if credit := v.Grade(answer{Capital: "Lome"}); credit != 0 {
+		t.Errorf("Lome graded %v as Lima", credit)
+	}
+}
+
+func TestClosestSpelling(t *testing.T) {
+	// South Africa's capitals, then German and Spanish ones
+	v := newTextValidator([]string{"Pretoria", "Cape Town", "Bloemfontein", "Kapstadt", "Ciudad del Cabo"}, 2)
+	tests := []struct {
+		given   string
+		credit  float64
+		closest string
+	}{
+		{"Pretoria", 1, "Pretoria"},
+		{"Cap Tovn", 0.5, "Cape Town"},
+		{"Bloemfontien", 1, "Bloemfontein"},
+		{"Kapstat", 1, "Kapstadt"},
+		{"ciudad del kabo", 1, "Ciudad del Cabo"},
+		{"Johannesburg", 0, "Pretoria"},
+	}
+	for _, test := range tests {
+		credit, closest := v.closest(answer{Capital: test.given})
+		if credit != test.credit || closest != test.closest {
+			t.Errorf("closest(%q) = %v, %q, want %v, %q", test.given, credit, closest, test.credit, test.closest)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"math/rand"
 	"net/http"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -22,6 +23,18 @@ var charset string = "ABCDEFGHIJKLMNOPQRSTUWVXYZabcdefghijklmnopqrstuvwyz1234567
 var gameSemaphore sync.WaitGroup = sync.WaitGroup{}
 
 var host *string= flag.String("host", "localhost:3434", "endpoint of game server")
+var mode *string= flag.String("mode", "capital", "question mode of the simulated game")
+var difficulty *string= flag.String("difficulty", "easy", "difficulty of the simulated game")
+var pack *string= flag.String("pack", "", "ID of the question pack to play")
+var seed *int64= flag.Int64("seed", 0, "seed of the simulated game, picked by the server when 0")
+var locale *string= flag.String("locale", "en", "language the simulated players are asked in")
+var adaptive *bool= flag.Bool("adaptive", false, "give each simulated player their own questions")
+var continents *string= flag.String("continents", "", "comma separated continents the questions are limited to")
+var sovereignOnly *bool= flag.Bool("sovereign", false, "leave dependent territories out of the questions")
+var minPopulation *int= flag.Int("min-population", 0, "smallest population of the countries asked about")
+var options *int= flag.Int("options", 0, "number of options of each question, the server's default when 0")
+var timeLimit *int= flag.Int("time-limit", 0, "seconds to answer each question, the server's default when 0")
+var hintKind *string= flag.String("hint", "", "hint the simulated players ask for before answering")
 
 type Game struct {
 	Conn *websocket.Conn
@@ -36,18 +49,30 @@ type message struct {
 
 type question struct {
 	Id string
+	Kind string
 	Country string
+	Capital string
+	Prompt string
+	Metric string
 	Options []string
+	OptionIds []string
 }
 
 type answer struct {
 	Id string
+	Kind string
+	OptionId string
+	Selections []string
+	Order []string
 	Capital string
+	Lat float64
+	Lon float64
 }
 
 type status struct {
 	Result bool `json:"result"`
 	Message string `json:"message"`
+	Reveal string `json:"reveal"`
 }
 
 type gameOver struct{
@@ -62,10 +87,22 @@ type startGame struct {
 type CreateGameRequest struct {
 	Players []string `json:"players"`
 	Rounds	int      `json:"rounds"`
+	Mode    string   `json:"mode"`
+	Difficulty string `json:"difficulty"`
+	Pack    string   `json:"pack"`
+	Seed    *int64   `json:"seed,omitempty"`
+	Adaptive bool    `json:"adaptive"`
+	Continents []string `json:"continents,omitempty"`
+	SovereignOnly bool  `json:"sovereignOnly"`
+	MinPopulation int   `json:"minPopulation"`
+	Options int         `json:"options"`
+	TimeLimit int       `json:"timeLimit"`
 }
 
 type CreateGameResponse struct {
 	GameID int `json:"gameID"`
+	Difficulty string `json:"difficulty"`
+	Seed int64 `json:"seed"`
 }
 
 
@@ -74,11 +111,30 @@ func playQuestion(q interface{}) answer {
 	mapstructure.Decode(q, &question)
 	s1 := rand.NewSource(time.Now().UnixNano())
 	r1 := rand.New(s1)
-	index := r1.Intn(len(question.Options))
-	return answer{
-		Id:      question.Id,
-		Capital: question.Options[index],
+	ans := answer{
+		Id:   question.Id,
+		Kind: question.Kind,
 	}
+	switch question.Kind {
+	case "coordinates":
+		ans.Lat = r1.Float64()*180 - 90
+		ans.Lon = r1.Float64()*360 - 180
+	case "freeText":
+		ans.Capital = generateStringWithCharset(charset, 6)
+	case "ordering":
+		for _, i := range r1.Perm(len(question.OptionIds)) {
+			ans.Order = append(ans.Order, question.OptionIds[i])
+		}
+	case "selectAll":
+		for _, id := range question.OptionIds {
+			if r1.Intn(2) == 0 {
+				ans.Selections = append(ans.Selections, id)
+			}
+		}
+	default:
+		ans.OptionId = question.OptionIds[r1.Intn(len(question.OptionIds))]
+	}
+	return ans
 }
 
 func (game *Game) initGame(socketURL string, player string, gameID int) {
@@ -94,6 +150,7 @@ func (game *Game) connectToSocket(url string) {
 	header.Add("Origin", " http://localhost:3434")
 	header.Add("userID", game.playerID)
 	header.Add("gameID", strconv.Itoa(game.ID))
+	header.Add("locale", *locale)
 
 	conn, resp, err := Dialer.Dial(url, header)
 	if err != nil {
@@ -119,11 +176,18 @@ func checkSocket(conn *websocket.Conn) {
 		case "acknowledged":
 			fmt.Printf("%s \n", m.Content)
 		case "timeout":
-			fmt.Printf("%s \n", m.Content)
+			status := status{}
+			mapstructure.Decode(m.Content, &status)
+			fmt.Println(status.Message)
 		case "question":
 			ans := playQuestion(m.Content)
+			if *hintKind != "" {
+				conn.WriteJSON(message{"hint", map[string]string{"id": ans.Id, "kind": *hintKind}})
+			}
 			resp := message{"answer", ans}
 			conn.WriteJSON(resp)
+		case "hint":
+			fmt.Printf("💡 %v \n", m.Content)
 		case "scoreUpdate":
 			scores := make(map[string]int)
 			mapstructure.Decode(m.Content, &scores)
@@ -131,6 +195,9 @@ func checkSocket(conn *websocket.Conn) {
 			status := status{}
 			mapstructure.Decode(m.Content, &status)
 			fmt.Println(status.Message)
+			if status.Reveal != "" {
+				fmt.Println(status.Reveal)
+			}
 		case "gameOver":
 			g := map[string]int{}
 			mapstructure.Decode(m.Content, &g)
@@ -152,11 +219,34 @@ func checkSocket(conn *websocket.Conn) {
 	}
 }
 
-func createGameSession(players[] string, rounds int) int{
+func createGameSession(players[] string, rounds int) CreateGameResponse{
 	createGameEndpoint := fmt.Sprintf("http://%s/game",*host)
+	var gameSeed *int64
+	if *seed != 0 {
+		gameSeed = seed
+	}
+	var gameContinents []string
+	if *continents != "" {
+		gameContinents = strings.Split(*continents, ",")
+	}
+	// packs bring their own questions, with no mode or difficulty
+	gameMode, gameDifficulty := *mode, *difficulty
+	if *pack != "" {
+		gameMode, gameDifficulty = "", ""
+	}
 	requestBody, err:= json.Marshal(CreateGameRequest{
 		Players: players,
 		Rounds:  rounds,
+		Mode:    gameMode,
+		Difficulty: gameDifficulty,
+		Pack:    *pack,
+		Seed:    gameSeed,
+		Adaptive: *adaptive,
+		Continents: gameContinents,
+		SovereignOnly: *sovereignOnly,
+		MinPopulation: *minPopulation,
+		Options: *options,
+		TimeLimit: *timeLimit,
 	})
 	if err != nil {
 		fmt.Println(err)
@@ -176,7 +266,7 @@ func createGameSession(players[] string, rounds int) int{
 	if (*r).StatusCode != http.StatusCreated{
 		panic("Couldn't create game")
 	}
-	return response.GameID
+	return response
 }
 
 func generateRandomInt(upperBound int) int{
@@ -209,13 +299,14 @@ func createMasterGame(ws_endpoint string){
 	for i:=0; i < len(players); i++{
 		players[i] = generateStringWithCharset(charset, 5)
 	}
-	gameID := createGameSession(players, generateRandomInt(11))
+	response := createGameSession(players, generateRandomInt(11))
+	gameID := response.GameID
 	gameSemaphore := sync.WaitGroup{}
 	for i:=0; i < len(players); i++{
 		gameSemaphore.Add(1)
 		go simulatePlayer(ws_endpoint, players[i], gameID, &gameSemaphore)
 	}
-	fmt.Printf("Started game: %d\n",gameID)
+	fmt.Printf("Started game: %d with seed %d\n",gameID, response.Seed)
 	gameSemaphore.Wait()
 	fmt.Printf("Finished game: %d\n",gameID)
 }